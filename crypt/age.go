@@ -0,0 +1,24 @@
+package crypt
+
+import (
+	"bytes"
+	"io"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// Age decrypts ciphertext, binary or armored, with the first of identities
+// that it was encrypted to.
+func Age(ciphertext []byte, identities []age.Identity) ([]byte, error) {
+	var reader io.Reader = bytes.NewReader(ciphertext)
+	if bytes.HasPrefix(bytes.TrimSpace(ciphertext), []byte(armor.Header)) {
+		reader = armor.NewReader(bytes.NewReader(bytes.TrimSpace(ciphertext)))
+	}
+
+	plaintext, err := age.Decrypt(reader, identities...)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(plaintext)
+}
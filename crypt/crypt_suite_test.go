@@ -0,0 +1,33 @@
+package crypt_test
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCrypt(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Crypt Suite")
+}
+
+// useTestGPGKey points gpg at a new home holding only the test key, and
+// leaves no age identities for SOPS to find.
+func useTestGPGKey() {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		Skip("gpg is not installed")
+	}
+
+	home := GinkgoT().TempDir()
+	GinkgoT().Setenv("HOME", home)
+	GinkgoT().Setenv("XDG_CONFIG_HOME", home)
+	GinkgoT().Setenv("GNUPGHOME", home)
+	GinkgoT().Setenv("SOPS_AGE_KEY_FILE", "")
+	GinkgoT().Setenv("SOPS_AGE_KEY", "")
+
+	output, err := exec.Command("gpg", "--batch", "--import", "../fixtures/gpg/secret-key.asc").CombinedOutput()
+	Ω(err).ShouldNot(HaveOccurred(), string(output))
+}
@@ -0,0 +1,100 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gpgExtensions are the extensions of files encrypted with gpg, which are
+// removed to find the format of the plaintext.
+var gpgExtensions = []string{".gpg", ".asc", ".pgp"}
+
+// IsGPG reports whether source is an OpenPGP encrypted message, either
+// ASCII-armored or binary. Binary messages start with a packet holding an
+// encrypted session key, whose header and version no text file has, even
+// one starting with a character such as é whose first byte looks like a
+// packet tag.
+func IsGPG(source []byte) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(source), []byte("-----BEGIN PGP MESSAGE-----")) {
+		return true
+	}
+	if len(source) == 0 || source[0]&0x80 == 0 {
+		return false
+	}
+
+	var tag byte
+	var body int
+	if source[0]&0x40 != 0 {
+		tag = source[0] & 0x3f
+		switch {
+		case len(source) < 2:
+			return false
+		case source[1] < 192:
+			body = 2
+		case source[1] < 224:
+			body = 3
+		case source[1] == 255:
+			body = 6
+		default:
+			// Partial lengths are only allowed for data packets.
+			return false
+		}
+	} else {
+		tag = (source[0] >> 2) & 0x0f
+		body = 1 + []int{1, 2, 4, 0}[source[0]&0x03]
+	}
+	if len(source) <= body {
+		return false
+	}
+
+	version := source[body]
+	switch tag {
+	case 1: // Public-key encrypted session key.
+		return version == 3 || version == 6
+	case 3: // Symmetric-key encrypted session key.
+		return version == 4 || version == 5 || version == 6
+	}
+	return false
+}
+
+// TrimGPGExtension removes the extension gpg adds to the name of a file it
+// encrypts, so that vars.yml.gpg is read as YAML.
+func TrimGPGExtension(location string) string {
+	extension := filepath.Ext(location)
+	for _, gpgExtension := range gpgExtensions {
+		if strings.EqualFold(extension, gpgExtension) {
+			return strings.TrimSuffix(location, extension)
+		}
+	}
+	return location
+}
+
+// GPG decrypts ciphertext, binary or ASCII-armored, by piping it through
+// the local gpg binary, so that the plaintext is only ever held in memory.
+// Keys, and any agent prompting for a passphrase, are whatever gpg is
+// configured to use.
+func GPG(ciphertext []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("gpg", "--batch", "--quiet", "--decrypt")
+	cmd.Stdin = bytes.NewReader(ciphertext)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		// gpg only reports on the keys and packets it saw, so its messages
+		// are safe to show.
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, errors.New(strings.ReplaceAll(message, "\n", "; "))
+		}
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
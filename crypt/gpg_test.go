@@ -0,0 +1,61 @@
+package crypt_test
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	. "github.com/EngineerBetter/yml2env/crypt"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GPG", func() {
+	BeforeEach(useTestGPGKey)
+
+	It("decrypts armored messages", func() {
+		encrypt := exec.Command("gpg", "--batch", "--armor", "--trust-model", "always", "--recipient", "tests@example.com", "--encrypt")
+		encrypt.Stdin = strings.NewReader("top secret")
+		ciphertext, err := encrypt.Output()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		plaintext, err := GPG(ciphertext)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(plaintext)).Should(Equal("top secret"))
+	})
+
+	It("reports what gpg says when it cannot decrypt", func() {
+		_, err := GPG([]byte("not encrypted"))
+		Ω(err).Should(MatchError(ContainSubstring("gpg: no valid OpenPGP data found")))
+	})
+
+	It("recognises encrypted messages, binary or armored", func() {
+		binary, err := os.ReadFile("../fixtures/gpg/local.yml.gpg")
+		Ω(err).ShouldNot(HaveOccurred())
+		armored, err := os.ReadFile("../fixtures/gpg/local.json.asc")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(IsGPG(binary)).Should(BeTrue())
+		Ω(IsGPG(armored)).Should(BeTrue())
+		Ω(IsGPG([]byte("var_from_yaml: value\n"))).Should(BeFalse())
+		Ω(IsGPG([]byte("\xef\xbb\xbfvar_from_yaml: value\n"))).Should(BeFalse())
+		Ω(IsGPG([]byte("énv: x\n"))).Should(BeFalse())
+		Ω(IsGPG([]byte("Ávila=1\n"))).Should(BeFalse())
+		Ω(IsGPG(nil)).Should(BeFalse())
+	})
+
+	It("decrypts binary messages", func() {
+		ciphertext, err := os.ReadFile("../fixtures/gpg/local.yml.gpg")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		plaintext, err := GPG(ciphertext)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(plaintext)).Should(ContainSubstring("var_from_yaml: value from gpg"))
+	})
+
+	It("removes the extensions gpg gives encrypted files", func() {
+		Ω(TrimGPGExtension("ci/local.yml.gpg")).Should(Equal("ci/local.yml"))
+		Ω(TrimGPGExtension("ci/local.json.ASC")).Should(Equal("ci/local.json"))
+		Ω(TrimGPGExtension("ci/local.yml")).Should(Equal("ci/local.yml"))
+	})
+})
@@ -0,0 +1,310 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/EngineerBetter/yml2env/input"
+	"github.com/EngineerBetter/yml2env/vars"
+	"gopkg.in/yaml.v2"
+)
+
+var sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// macOnlyEncrypted starts the MAC of files whose mac_only_encrypted is set,
+// as SOPS does, so that it never matches a MAC of every value.
+var macOnlyEncrypted = []byte{0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0xb, 0xb, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69}
+
+// IsSOPS reports whether documents were encrypted by SOPS, which adds a sops
+// map of metadata to each of them.
+func IsSOPS(documents []input.Document) bool {
+	if len(documents) == 0 {
+		return false
+	}
+	metadata, ok := field(documents[0].Vars, "sops").(yaml.MapSlice)
+	return ok && field(metadata, "mac") != nil
+}
+
+// SOPS decrypts documents encrypted by SOPS and checks their MAC, removing
+// the sops metadata. The data key is decrypted with an age identity from
+// SOPS_AGE_KEY, SOPS_AGE_KEY_FILE or the SOPS keys.txt, or failing that
+// with a PGP key known to gpg. Decrypted strings are recorded as sensitive.
+func SOPS(documents []input.Document) ([]input.Document, error) {
+	metadata, _ := field(documents[0].Vars, "sops").(yaml.MapSlice)
+	key, err := sopsDataKey(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	d := sopsDecrypter{key: key, hash: sha512.New(), macOnlyEncrypted: field(metadata, "mac_only_encrypted") == true}
+	if d.macOnlyEncrypted {
+		d.hash.Write(macOnlyEncrypted)
+	}
+
+	decrypted := make([]input.Document, len(documents))
+	for i, document := range documents {
+		d.sensitive = nil
+		tree := yaml.MapSlice{}
+		for _, item := range document.Vars {
+			if item.Key != "sops" {
+				tree = append(tree, item)
+			}
+		}
+
+		value, err := d.decrypt(tree, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		decrypted[i] = input.Document{Name: document.Name, Vars: value.(yaml.MapSlice), Sensitive: append(document.Sensitive, d.sensitive...)}
+	}
+
+	lastModified, _ := vars.Scalar(field(metadata, "lastmodified"))
+	mac, _ := field(metadata, "mac").(string)
+	expected, _, err := d.decryptValue(mac, lastModified)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt the MAC: %s", err)
+	}
+	if expected != fmt.Sprintf("%X", d.hash.Sum(nil)) {
+		return nil, errors.New("the MAC does not match the values, so the file may have been tampered with")
+	}
+
+	return decrypted, nil
+}
+
+type sopsDecrypter struct {
+	key              []byte
+	hash             hash.Hash
+	macOnlyEncrypted bool
+	sensitive        []vars.Path
+}
+
+// decrypt walks value the way SOPS does, authenticating each encrypted
+// value with the keys on its path, and adding every value to the MAC.
+// Elements of lists share the path of the list, so location is where value
+// really is, to record it as sensitive.
+func (d *sopsDecrypter) decrypt(value interface{}, path, location vars.Path) (interface{}, error) {
+	switch typed := value.(type) {
+	case yaml.MapSlice:
+		decrypted := make(yaml.MapSlice, len(typed))
+		for i, item := range typed {
+			itemPath := append(append(vars.Path{}, path...), fmt.Sprint(item.Key))
+			itemLocation := append(append(vars.Path{}, location...), fmt.Sprint(item.Key))
+			itemValue, err := d.decrypt(item.Value, itemPath, itemLocation)
+			if err != nil {
+				return nil, err
+			}
+			decrypted[i] = yaml.MapItem{Key: item.Key, Value: itemValue}
+		}
+		return decrypted, nil
+	case []interface{}:
+		decrypted := make([]interface{}, len(typed))
+		for i, element := range typed {
+			var err error
+			elementLocation := append(append(vars.Path{}, location...), fmt.Sprint(i))
+			if decrypted[i], err = d.decrypt(element, path, elementLocation); err != nil {
+				return nil, err
+			}
+		}
+		return decrypted, nil
+	case nil:
+		return nil, nil
+	case string:
+		if sopsValue.MatchString(typed) {
+			plaintext, text, err := d.decryptValue(typed, strings.Join(path, ":")+":")
+			if err != nil {
+				return nil, fmt.Errorf("could not decrypt %s: %s", strings.Join(path, "."), err)
+			}
+			d.hash.Write([]byte(text))
+			d.sensitive = append(d.sensitive, location)
+			return plaintext, nil
+		}
+	}
+
+	if !d.macOnlyEncrypted {
+		d.hash.Write([]byte(sopsBytes(value)))
+	}
+	return value, nil
+}
+
+// decryptValue decrypts an ENC[AES256_GCM,...] value, returning it with its
+// original type, along with the text SOPS adds to the MAC for it.
+func (d *sopsDecrypter) decryptValue(encrypted, additionalData string) (interface{}, string, error) {
+	if encrypted == "" {
+		return "", "", nil
+	}
+	parts := sopsValue.FindStringSubmatch(encrypted)
+	if parts == nil {
+		return nil, "", errors.New("it is not a SOPS encrypted value")
+	}
+
+	var fields [3][]byte
+	for i := range fields {
+		var err error
+		if fields[i], err = base64.StdEncoding.DecodeString(parts[i+1]); err != nil {
+			return nil, "", errors.New("it is not valid base64")
+		}
+	}
+	data, iv, tag := fields[0], fields[1], fields[2]
+
+	block, err := aes.NewCipher(d.key)
+	if err != nil {
+		return nil, "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, "", errors.New("the data key or the value is wrong")
+	}
+
+	text := string(plaintext)
+	switch parts[4] {
+	case "str":
+		return text, text, nil
+	case "bytes":
+		return plaintext, text, nil
+	case "int":
+		number, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, "", errors.New("it is not an int")
+		}
+		return number, text, nil
+	case "float":
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, "", errors.New("it is not a float")
+		}
+		return number, sopsBytes(number), nil
+	case "bool":
+		boolean, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, "", errors.New("it is not a bool")
+		}
+		return boolean, sopsBytes(boolean), nil
+	}
+	return nil, "", fmt.Errorf("it has unknown type %s", parts[4])
+}
+
+// sopsBytes is the text SOPS adds to the MAC for a plaintext value.
+func sopsBytes(value interface{}) string {
+	switch typed := value.(type) {
+	case bool:
+		if typed {
+			return "True"
+		}
+		return "False"
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	}
+	text, _ := vars.Scalar(value)
+	return text
+}
+
+func sopsDataKey(metadata yaml.MapSlice) ([]byte, error) {
+	groups := []interface{}{metadata}
+	if keyGroups, ok := field(metadata, "key_groups").([]interface{}); ok {
+		if len(keyGroups) > 1 {
+			return nil, errors.New("SOPS files with more than one key group are not supported")
+		}
+		groups = keyGroups
+	}
+
+	var failures []string
+	for _, group := range groups {
+		groupMap, _ := group.(yaml.MapSlice)
+
+		if ageKeys, _ := field(groupMap, "age").([]interface{}); len(ageKeys) > 0 {
+			identities, err := sopsAgeIdentities()
+			if err != nil {
+				return nil, err
+			}
+			for _, ageKey := range ageKeys {
+				ageMap, _ := ageKey.(yaml.MapSlice)
+				enc, _ := field(ageMap, "enc").(string)
+				if len(identities) == 0 {
+					failures = append(failures, fmt.Sprintf("age %v: there are no age identities", field(ageMap, "recipient")))
+					continue
+				}
+				key, err := Age([]byte(enc), identities)
+				if err == nil {
+					return key, nil
+				}
+				failures = append(failures, fmt.Sprintf("age %v: %s", field(ageMap, "recipient"), err))
+			}
+		}
+
+		pgpKeys, _ := field(groupMap, "pgp").([]interface{})
+		for _, pgpKey := range pgpKeys {
+			pgpMap, _ := pgpKey.(yaml.MapSlice)
+			enc, _ := field(pgpMap, "enc").(string)
+			key, err := GPG([]byte(enc))
+			if err == nil {
+				return key, nil
+			}
+			failures = append(failures, fmt.Sprintf("pgp %v: %s", field(pgpMap, "fp"), err))
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil, errors.New("could not decrypt the SOPS data key: only age and PGP keys are supported")
+	}
+	return nil, errors.New("could not decrypt the SOPS data key:\n  " + strings.Join(failures, "\n  "))
+}
+
+// sopsAgeIdentities reads age identities from the same places as SOPS.
+func sopsAgeIdentities() ([]age.Identity, error) {
+	var identities []age.Identity
+
+	if keys := os.Getenv("SOPS_AGE_KEY"); keys != "" {
+		parsed, err := age.ParseIdentities(strings.NewReader(keys))
+		if err != nil {
+			return nil, fmt.Errorf("SOPS_AGE_KEY: %s", err)
+		}
+		identities = append(identities, parsed...)
+	}
+
+	path, required := os.Getenv("SOPS_AGE_KEY_FILE"), true
+	if path == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return identities, nil
+		}
+		path, required = filepath.Join(configDir, "sops", "age", "keys.txt"), false
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) && !required {
+		return identities, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	parsed, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return append(identities, parsed...), nil
+}
+
+func field(mapSlice yaml.MapSlice, key string) interface{} {
+	for _, item := range mapSlice {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+	return nil
+}
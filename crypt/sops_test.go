@@ -0,0 +1,91 @@
+package crypt_test
+
+import (
+	"os"
+	"strings"
+
+	. "github.com/EngineerBetter/yml2env/crypt"
+	"github.com/EngineerBetter/yml2env/input"
+	"github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("SOPS", func() {
+	var documents []input.Document
+
+	BeforeEach(func() {
+		source, err := os.ReadFile("../fixtures/sops/vars.yml")
+		Ω(err).ShouldNot(HaveOccurred())
+		documents, err = input.Parse(input.FormatYAML, source, false, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	plaintext := yaml.MapSlice{
+		{Key: "var_from_yaml", Value: "value from sops"},
+		{Key: "database", Value: yaml.MapSlice{
+			{Key: "password", Value: "hunter2"},
+			{Key: "port", Value: 5432},
+		}},
+		{Key: "debug", Value: true},
+		{Key: "ratio", Value: 1.5},
+		{Key: "zones", Value: []interface{}{"eu-west-2a", "eu-west-2b"}},
+		{Key: "region_unencrypted", Value: "eu-west-2"},
+	}
+
+	It("recognises files encrypted by SOPS", func() {
+		Ω(IsSOPS(documents)).Should(BeTrue())
+		Ω(IsSOPS([]input.Document{{Vars: yaml.MapSlice{{Key: "sops", Value: "a string"}}}})).Should(BeFalse())
+	})
+
+	It("decrypts with an age identity, recording every decrypted value as sensitive", func() {
+		GinkgoT().Setenv("SOPS_AGE_KEY_FILE", "../fixtures/sops/age.key")
+
+		decrypted, err := SOPS(documents)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(decrypted).Should(HaveLen(1))
+		Ω(decrypted[0].Vars).Should(Equal(plaintext))
+		Ω(decrypted[0].Sensitive).Should(Equal([]vars.Path{{"var_from_yaml"}, {"database", "password"}, {"database", "port"}, {"debug"}, {"ratio"}, {"zones", "0"}, {"zones", "1"}}))
+	})
+
+	It("decrypts with an age identity from SOPS_AGE_KEY", func() {
+		key, err := os.ReadFile("../fixtures/sops/age.key")
+		Ω(err).ShouldNot(HaveOccurred())
+		GinkgoT().Setenv("SOPS_AGE_KEY_FILE", "")
+		GinkgoT().Setenv("SOPS_AGE_KEY", string(key))
+
+		decrypted, err := SOPS(documents)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(decrypted[0].Vars).Should(Equal(plaintext))
+	})
+
+	It("decrypts with a PGP key", func() {
+		useTestGPGKey()
+
+		decrypted, err := SOPS(documents)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(decrypted[0].Vars).Should(Equal(plaintext))
+	})
+
+	It("rejects files whose values have been changed", func() {
+		GinkgoT().Setenv("SOPS_AGE_KEY_FILE", "../fixtures/sops/age.key")
+		source, err := os.ReadFile("../fixtures/sops/vars.yml")
+		Ω(err).ShouldNot(HaveOccurred())
+		tampered := strings.Replace(string(source), "region_unencrypted: eu-west-2", "region_unencrypted: us-east-1", 1)
+		documents, err = input.Parse(input.FormatYAML, []byte(tampered), false, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = SOPS(documents)
+		Ω(err).Should(MatchError("the MAC does not match the values, so the file may have been tampered with"))
+	})
+
+	It("explains why the data key could not be decrypted", func() {
+		useTestGPGKey()
+		GinkgoT().Setenv("GNUPGHOME", GinkgoT().TempDir())
+
+		_, err := SOPS(documents)
+		Ω(err).Should(MatchError(ContainSubstring("there are no age identities")))
+		Ω(err).Should(MatchError(ContainSubstring("No secret key")))
+	})
+})
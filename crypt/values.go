@@ -0,0 +1,494 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/EngineerBetter/yml2env/vars"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// EncryptedTag marks a YAML value that EncryptValues has replaced with an
+// armored age message.
+const EncryptedTag = "!encrypted"
+
+// HasEncryptedValues reports whether source may contain values tagged with
+// EncryptedTag.
+func HasEncryptedValues(source []byte) bool {
+	return bytes.Contains(source, []byte(EncryptedTag))
+}
+
+// EncryptValues encrypts every scalar value at or beneath paths, or every
+// scalar value if there are no paths, to recipients. Each becomes a literal
+// block of armored age text tagged with EncryptedTag, or a double-quoted one
+// inside a flow collection. The encrypted text is the scalar as it was
+// written, so that decrypting it restores its quoting and type. Nothing else
+// in the file is changed, and values that are already encrypted are left as
+// they are.
+func EncryptValues(source []byte, paths []vars.Path, recipients []age.Recipient) ([]byte, error) {
+	return editValues(source, paths, func(s *scalar) (string, bool, error) {
+		if s.node.Tag == EncryptedTag || s.node.ShortTag() == "!!null" {
+			return "", false, nil
+		}
+
+		var armored bytes.Buffer
+		armorWriter := armor.NewWriter(&armored)
+		writer, err := age.Encrypt(armorWriter, recipients...)
+		if err != nil {
+			return "", false, err
+		}
+		if _, err := writer.Write([]byte(s.text)); err != nil {
+			return "", false, err
+		}
+		if err := writer.Close(); err != nil {
+			return "", false, err
+		}
+		if err := armorWriter.Close(); err != nil {
+			return "", false, err
+		}
+
+		s.node.Tag, s.node.Value = EncryptedTag, armored.String()
+		if s.flow {
+			return EncryptedTag + ` "` + strings.ReplaceAll(armored.String(), "\n", `\n`) + `"`, true, nil
+		}
+		indent := strings.Repeat(" ", s.indent+2)
+		lines := strings.Split(strings.TrimSuffix(armored.String(), "\n"), "\n")
+		return EncryptedTag + " |\n" + indent + strings.Join(lines, "\n"+indent), true, nil
+	})
+}
+
+// DecryptValues restores the values at or beneath paths, or every value if
+// there are no paths, that EncryptValues encrypted. It also returns the
+// paths of the values it decrypted in each document of the stream, so that
+// they can be kept out of diagnostic output.
+func DecryptValues(source []byte, paths []vars.Path, identities []age.Identity) ([]byte, [][]vars.Path, error) {
+	var decryptedPaths [][]vars.Path
+
+	decrypted, err := editValues(source, paths, func(s *scalar) (string, bool, error) {
+		if s.node.Tag != EncryptedTag {
+			return "", false, nil
+		}
+		if len(identities) == 0 {
+			return "", false, fmt.Errorf("could not decrypt %s: no age identity was given", s.path.String())
+		}
+
+		payload, err := Age([]byte(s.node.Value), identities)
+		if err != nil {
+			return "", false, fmt.Errorf("could not decrypt %s: %s", s.path.String(), err)
+		}
+
+		// The payload lacks the line break that ends its last line.
+		var document yamlv3.Node
+		if err := yamlv3.Unmarshal(append(payload, '\n'), &document); err != nil || len(document.Content) != 1 || document.Content[0].Kind != yamlv3.ScalarNode {
+			return "", false, fmt.Errorf("could not decrypt %s: it is not an encrypted scalar", s.path.String())
+		}
+		plain := document.Content[0]
+
+		s.node.Tag, s.node.Value = plain.Tag, plain.Value
+		for len(decryptedPaths) <= s.document {
+			decryptedPaths = append(decryptedPaths, nil)
+		}
+		decryptedPaths[s.document] = append(decryptedPaths[s.document], s.path)
+		return string(payload), true, nil
+	})
+
+	return decrypted, decryptedPaths, err
+}
+
+// ReadIdentities reads the age identities in the file at path.
+func ReadIdentities(path string) ([]age.Identity, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	identities, err := age.ParseIdentities(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return identities, nil
+}
+
+// ParseRecipients reads age recipients, each either given directly or in a
+// file of recipients when prefixed with @.
+func ParseRecipients(values []string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+
+	for _, value := range values {
+		if strings.HasPrefix(value, "@") {
+			file, err := os.Open(value[1:])
+			if err != nil {
+				return nil, err
+			}
+			parsed, err := age.ParseRecipients(file)
+			file.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", value[1:], err)
+			}
+			recipients = append(recipients, parsed...)
+			continue
+		}
+
+		recipient, err := age.ParseX25519Recipient(value)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients given")
+	}
+	return recipients, nil
+}
+
+// scalar is a scalar value as it is written in a document.
+type scalar struct {
+	node *yamlv3.Node
+	path vars.Path
+	// document is the index of the document in the stream it is in.
+	document int
+	// text is the scalar as it is written, with its tag but not its anchor,
+	// and with the lines of a block scalar after its header.
+	text string
+	// flow is whether the scalar is inside a flow collection.
+	flow bool
+	// indent is the indentation of the key or sequence entry the scalar is
+	// the value of.
+	indent int
+
+	// source[start:end] holds its properties and value, or its properties
+	// and header if it is a block scalar, whose lines are then held by
+	// source[bodyStart:bodyEnd]. For other scalars, bodyStart and bodyEnd are
+	// both the end of their last line.
+	start, end, bodyStart, bodyEnd int
+	anchor                         string
+}
+
+// editValues calls edit with every scalar value in every document of source
+// that is at or beneath one of paths. Where edit gives the scalar new text,
+// having changed its node to match, the text is spliced into source in place
+// of the old, so that the rest of the file is left byte for byte as it was.
+// The result is checked to read back as the edited documents.
+func editValues(source []byte, paths []vars.Path, edit func(*scalar) (string, bool, error)) ([]byte, error) {
+	decoder := yamlv3.NewDecoder(bytes.NewReader(source))
+	var documents []*yamlv3.Node
+	var scalars []*scalar
+
+	for {
+		var document yamlv3.Node
+		if err := decoder.Decode(&document); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		first := len(scalars)
+		scalars = walkValues(&document, nil, paths, false, -1, scalars)
+		for _, s := range scalars[first:] {
+			s.document = len(documents)
+		}
+		documents = append(documents, &document)
+	}
+
+	lines := lineOffsets(source)
+	var output bytes.Buffer
+	offset, changed := 0, false
+	for _, s := range scalars {
+		// Empty values cannot always be found, but are never edited.
+		located := s.locate(source, lines)
+		text, edited, err := edit(s)
+		if err != nil {
+			return nil, err
+		}
+		if !edited {
+			continue
+		}
+		if located != nil {
+			return nil, located
+		}
+		if s.start < offset {
+			return nil, fmt.Errorf("could not find %s", s.path.String())
+		}
+		changed = true
+
+		head, body := text, ""
+		if newline := strings.Index(text, "\n"); newline >= 0 && isBlock(text) {
+			head, body = text[:newline], text[newline:]
+		}
+		if s.anchor != "" {
+			head = s.anchor + " " + head
+		}
+
+		output.Write(source[offset:s.start])
+		output.WriteString(head)
+		offset = s.end
+		if body != "" || s.bodyEnd > s.bodyStart {
+			output.Write(source[s.end:s.bodyStart])
+			output.WriteString(body)
+			// A block ending a file without a final line break has none.
+			if s.bodyEnd == len(source) {
+				s.node.Value = strings.TrimSuffix(s.node.Value, "\n")
+			}
+			offset = s.bodyEnd
+		}
+	}
+	if !changed {
+		return source, nil
+	}
+	output.Write(source[offset:])
+
+	decoder = yamlv3.NewDecoder(bytes.NewReader(output.Bytes()))
+	for _, document := range documents {
+		var written yamlv3.Node
+		if err := decoder.Decode(&written); err != nil || !sameNodes(document, &written) {
+			return nil, errors.New("could not change the values without changing the rest of the file")
+		}
+	}
+	return output.Bytes(), nil
+}
+
+func walkValues(node *yamlv3.Node, path vars.Path, paths []vars.Path, flow bool, indent int, scalars []*scalar) []*scalar {
+	flow = flow || node.Style&yamlv3.FlowStyle != 0
+
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		for _, child := range node.Content {
+			scalars = walkValues(child, path, paths, flow, indent, scalars)
+		}
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			scalars = walkValues(node.Content[i+1], append(append(vars.Path{}, path...), node.Content[i].Value), paths, flow, node.Content[i].Column-1, scalars)
+		}
+	case yamlv3.SequenceNode:
+		for i, child := range node.Content {
+			scalars = walkValues(child, append(append(vars.Path{}, path...), fmt.Sprint(i)), paths, flow, node.Column-1, scalars)
+		}
+	case yamlv3.ScalarNode:
+		if selected(path, paths) {
+			scalars = append(scalars, &scalar{node: node, path: path, flow: flow, indent: indent})
+		}
+	}
+	return scalars
+}
+
+// locate finds where the scalar is written in source, whose lines start at
+// the offsets in lines.
+func (s *scalar) locate(source []byte, lines []int) error {
+	s.start = lines[s.node.Line-1]
+	for column := 1; column < s.node.Column; column++ {
+		_, size := utf8.DecodeRune(source[s.start:])
+		s.start += size
+	}
+
+	i := s.start
+	anchorStart, anchorEnd := i, i
+	for i < len(source) && (source[i] == '&' || source[i] == '!') {
+		property := i
+		for i < len(source) && !strings.ContainsRune(" \t\r\n,[]{}", rune(source[i])) {
+			i++
+		}
+		if source[property] == '&' {
+			s.anchor = string(source[property:i])
+			anchorStart = property
+		}
+		for i < len(source) && strings.ContainsRune(" \t\r\n", rune(source[i])) {
+			i++
+		}
+		if source[property] == '&' {
+			anchorEnd = i
+		}
+	}
+	if i >= len(source) {
+		return fmt.Errorf("could not find %s", s.path.String())
+	}
+
+	indicator, header := source[i], i
+	switch indicator {
+	case '"':
+		for i++; i < len(source) && source[i] != '"'; i++ {
+			if source[i] == '\\' {
+				i++
+			}
+		}
+		s.end = i + 1
+	case '\'':
+		for i++; i < len(source); i++ {
+			if source[i] == '\'' {
+				if i+1 < len(source) && source[i+1] == '\'' {
+					i++
+					continue
+				}
+				break
+			}
+		}
+		s.end = i + 1
+	case '|', '>':
+		for i++; i < len(source) && strings.ContainsRune("+-0123456789", rune(source[i])); i++ {
+		}
+		s.end = i
+	default:
+		s.end = s.plainEnd(source, i)
+	}
+	if s.end > len(source) {
+		return fmt.Errorf("could not find the end of %s", s.path.String())
+	}
+
+	s.bodyStart = lineEnd(source, s.end)
+	s.bodyEnd = s.bodyStart
+	if indicator == '|' || indicator == '>' {
+		s.bodyEnd = s.blockEnd(source, strings.Contains(string(source[header:s.end]), "+"))
+	}
+
+	s.text = string(source[s.start:anchorStart]) + string(source[anchorEnd:s.end]) + string(source[s.bodyStart:s.bodyEnd])
+	return nil
+}
+
+// plainEnd finds the end of a plain scalar starting at offset i, which may
+// continue onto lines indented beneath it.
+func (s *scalar) plainEnd(source []byte, i int) int {
+	end := i
+	for {
+		for i < len(source) && source[i] != '\n' && source[i] != '\r' {
+			if (source[i] == ' ' || source[i] == '\t') && i+1 < len(source) && source[i+1] == '#' {
+				return end
+			}
+			if s.flow && strings.ContainsRune(",[]{}", rune(source[i])) {
+				return end
+			}
+			if source[i] != ' ' && source[i] != '\t' {
+				end = i + 1
+			}
+			i++
+		}
+		if s.flow {
+			return end
+		}
+
+		next := nextLine(source, i)
+		for next < len(source) && isBlank(source[next:lineEnd(source, next)]) {
+			next = nextLine(source, next)
+		}
+		if next >= len(source) {
+			return end
+		}
+		line := source[next:lineEnd(source, next)]
+		indent := len(line) - len(bytes.TrimLeft(line, " "))
+		content := bytes.TrimLeft(line, " ")
+		if indent <= s.indent || content[0] == '#' || indent == 0 && (bytes.HasPrefix(content, []byte("---")) || bytes.HasPrefix(content, []byte("..."))) {
+			return end
+		}
+		i = next + indent
+	}
+}
+
+// blockEnd finds the end of the last line of a block scalar whose header
+// ends at s.bodyStart, including any blank lines after it that are kept.
+func (s *scalar) blockEnd(source []byte, keep bool) int {
+	end, contentIndent := s.bodyStart, -1
+	for next := nextLine(source, s.bodyStart); next < len(source); next = nextLine(source, next) {
+		line := source[next:lineEnd(source, next)]
+		if isBlank(line) {
+			if keep && contentIndent >= 0 {
+				end = lineEnd(source, next)
+			}
+			continue
+		}
+		indent := len(line) - len(bytes.TrimLeft(line, " "))
+		if contentIndent < 0 {
+			if indent <= s.indent {
+				break
+			}
+			contentIndent = indent
+		}
+		if indent < contentIndent {
+			break
+		}
+		end = lineEnd(source, next)
+	}
+	return end
+}
+
+// isBlock reports whether text is a block scalar, after any tag.
+func isBlock(text string) bool {
+	if strings.HasPrefix(text, "!") {
+		if space := strings.IndexAny(text, " \n"); space >= 0 {
+			text = strings.TrimLeft(text[space:], " \n")
+		}
+	}
+	return strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">")
+}
+
+func isBlank(line []byte) bool {
+	return len(bytes.Trim(line, " \t\r")) == 0
+}
+
+// lineEnd returns the offset of the end of the line containing offset i,
+// before any carriage return.
+func lineEnd(source []byte, i int) int {
+	end := bytes.IndexByte(source[i:], '\n')
+	if end < 0 {
+		return len(source)
+	}
+	if end > 0 && source[i+end-1] == '\r' {
+		end--
+	}
+	return i + end
+}
+
+// nextLine returns the offset of the start of the line after the one
+// containing offset i.
+func nextLine(source []byte, i int) int {
+	next := bytes.IndexByte(source[i:], '\n')
+	if next < 0 {
+		return len(source)
+	}
+	return i + next + 1
+}
+
+func lineOffsets(source []byte) []int {
+	lines := []int{0}
+	for i, b := range source {
+		if b == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// sameNodes reports whether a and b hold the same values, whatever their
+// styles and comments.
+func sameNodes(a, b *yamlv3.Node) bool {
+	if a.Kind != b.Kind || a.Value != b.Value || a.Anchor != b.Anchor || len(a.Content) != len(b.Content) {
+		return false
+	}
+	if a.Kind == yamlv3.ScalarNode && a.ShortTag() != b.ShortTag() {
+		return false
+	}
+	for i := range a.Content {
+		if !sameNodes(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func selected(path vars.Path, paths []vars.Path) bool {
+	if len(paths) == 0 {
+		return true
+	}
+
+	for _, prefix := range paths {
+		if len(prefix) <= len(path) && strings.Join(prefix, "\x00") == strings.Join(path[:len(prefix)], "\x00") {
+			return true
+		}
+	}
+	return false
+}
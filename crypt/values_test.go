@@ -0,0 +1,151 @@
+package crypt_test
+
+import (
+	"os"
+
+	"filippo.io/age"
+	. "github.com/EngineerBetter/yml2env/crypt"
+	"github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("encrypted values", func() {
+	var identities []age.Identity
+	var recipients []age.Recipient
+
+	source := []byte(`# settings
+name: app # the name
+database:
+  password: "hunter2"
+  port: 5432
+zones:
+  - eu-west-2a
+empty: null
+`)
+
+	BeforeEach(func() {
+		var err error
+		identities, err = ReadIdentities("../fixtures/sops/age.key")
+		Ω(err).ShouldNot(HaveOccurred())
+		recipients, err = ParseRecipients([]string{"age1zsw8ky6kyazz9hedh4hc4rjndv238x60xcn2qslvy20fftj8suvsgde76s"})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("encrypts every value and decrypts them back to the same YAML", func() {
+		encrypted, err := EncryptValues(source, nil, recipients)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(HasEncryptedValues(encrypted)).Should(BeTrue())
+		Ω(string(encrypted)).Should(ContainSubstring("# settings\nname: !encrypted | # the name\n"))
+		Ω(string(encrypted)).ShouldNot(ContainSubstring("hunter2"))
+		Ω(string(encrypted)).Should(ContainSubstring("empty: null"))
+
+		decrypted, paths, err := DecryptValues(encrypted, nil, identities)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(decrypted)).Should(Equal(string(source)))
+		Ω(paths).Should(Equal([][]vars.Path{{{"name"}, {"database", "password"}, {"database", "port"}, {"zones", "0"}}}))
+	})
+
+	It("only encrypts and decrypts values beneath the given paths", func() {
+		encrypted, err := EncryptValues(source, []vars.Path{{"database", "password"}, {"zones"}}, recipients)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(encrypted)).Should(ContainSubstring("name: app # the name\n"))
+		Ω(string(encrypted)).Should(ContainSubstring("port: 5432\n"))
+		Ω(string(encrypted)).ShouldNot(ContainSubstring("hunter2"))
+		Ω(string(encrypted)).ShouldNot(ContainSubstring("eu-west-2a"))
+
+		decrypted, paths, err := DecryptValues(encrypted, []vars.Path{{"zones"}}, identities)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(paths).Should(Equal([][]vars.Path{{{"zones", "0"}}}))
+		Ω(string(decrypted)).Should(ContainSubstring("- eu-west-2a\n"))
+		Ω(string(decrypted)).Should(ContainSubstring("password: !encrypted |"))
+	})
+
+	It("leaves the rest of the file byte for byte as it was", func() {
+		layout := []byte(`name:    app    #   spaced out
+
+
+nested:
+    quoted:   'it''s'
+    block: |   # kept
+        line one
+
+        line two
+
+    kept: |+
+        trailing
+
+list:
+- x
+-   "y"
+flow: {k: v, l: [1, 'two']}
+anchored: &a !!str value
+alias: *a
+`)
+
+		encrypted, err := EncryptValues(layout, nil, recipients)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(encrypted)).Should(HavePrefix("name:    !encrypted |    #   spaced out\n  -----BEGIN AGE ENCRYPTED FILE-----\n"))
+		Ω(string(encrypted)).Should(ContainSubstring("-----END AGE ENCRYPTED FILE-----\n\n\nnested:\n    quoted:   !encrypted |\n"))
+		Ω(string(encrypted)).Should(ContainSubstring("    block: !encrypted |   # kept\n"))
+		Ω(string(encrypted)).Should(ContainSubstring("list:\n- !encrypted |\n  -----BEGIN AGE ENCRYPTED FILE-----\n"))
+		Ω(string(encrypted)).Should(ContainSubstring(`flow: {k: !encrypted "-----BEGIN AGE ENCRYPTED FILE-----\n`))
+		Ω(string(encrypted)).Should(ContainSubstring("anchored: &a !encrypted |\n"))
+		Ω(string(encrypted)).Should(ContainSubstring("alias: *a\n"))
+
+		decrypted, _, err := DecryptValues(encrypted, nil, identities)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(decrypted)).Should(Equal(string(layout)))
+	})
+
+	It("leaves values that are already encrypted alone", func() {
+		encrypted, err := EncryptValues(source, nil, recipients)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		again, err := EncryptValues(encrypted, nil, recipients)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(again).Should(Equal(encrypted))
+	})
+
+	It("decrypts the fixture written by yml2env encrypt", func() {
+		fixture, err := os.ReadFile("../fixtures/encrypted.yml")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, paths, err := DecryptValues(fixture, nil, identities)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(paths).Should(Equal([][]vars.Path{{{"var_from_yaml"}, {"database", "password"}}}))
+	})
+
+	It("gives the paths it decrypted document by document", func() {
+		stream := []byte("a: 1\n---\nb: 2\n---\nc: 3\n")
+		encrypted, err := EncryptValues(stream, []vars.Path{{"a"}, {"c"}}, recipients)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, paths, err := DecryptValues(encrypted, nil, identities)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(paths).Should(Equal([][]vars.Path{{{"a"}}, nil, {{"c"}}}))
+	})
+
+	It("fails without an identity", func() {
+		encrypted, err := EncryptValues(source, []vars.Path{{"database"}}, recipients)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, _, err = DecryptValues(encrypted, nil, nil)
+		Ω(err).Should(MatchError("could not decrypt /database/password: no age identity was given"))
+	})
+
+	It("fails with an identity the values were not encrypted to", func() {
+		encrypted, err := EncryptValues(source, []vars.Path{{"name"}}, recipients)
+		Ω(err).ShouldNot(HaveOccurred())
+		other, err := age.GenerateX25519Identity()
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, _, err = DecryptValues(encrypted, nil, []age.Identity{other})
+		Ω(err).Should(MatchError(ContainSubstring("could not decrypt /name: no identity matched any of the recipients")))
+	})
+
+	It("requires a recipient", func() {
+		_, err := ParseRecipients(nil)
+		Ω(err).Should(MatchError("no recipients given"))
+	})
+})
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"filippo.io/age"
+	"github.com/EngineerBetter/yml2env/crypt"
+	"github.com/EngineerBetter/yml2env/source"
+	"github.com/EngineerBetter/yml2env/vars"
+)
+
+const identityEnv = "YML2ENV_IDENTITY"
+
+type editOptions struct {
+	recipients []string
+	identity   string
+	inPlace    bool
+
+	location string
+	paths    []vars.Path
+}
+
+func newEditFlagSet(command string, opts *editOptions) *flag.FlagSet {
+	flags := flag.NewFlagSet("yml2env "+command, flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+
+	if command == "encrypt" {
+		flags.Var((*stringsFlag)(&opts.recipients), "r", "age `recipient` to encrypt to, or @file to read recipients from a file; may be repeated")
+	} else {
+		flags.StringVar(&opts.identity, "identity", os.Getenv(identityEnv), "age identity `file` to decrypt with; defaults to $"+identityEnv)
+	}
+	flags.BoolVar(&opts.inPlace, "i", false, "rewrite the file instead of printing the result")
+
+	return flags
+}
+
+// parseEditArgs accepts options either side of the YAML file and the paths
+// of the values to change.
+func parseEditArgs(command string, args []string) (editOptions, error) {
+	opts := editOptions{}
+	flags := newEditFlagSet(command, &opts)
+
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return opts, err
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if len(positional) == 0 {
+		return opts, errors.New("no YAML file given")
+	}
+	opts.location = positional[0]
+
+	for _, expression := range positional[1:] {
+		path, err := vars.ParsePath(expression)
+		if err != nil {
+			return opts, err
+		}
+		opts.paths = append(opts.paths, path)
+	}
+
+	if opts.inPlace && opts.location == source.Stdin {
+		return opts, errors.New("standard input cannot be rewritten in place")
+	}
+	return opts, nil
+}
+
+// runEdit implements the encrypt and decrypt commands, which replace values
+// in a YAML file with age-encrypted ones and back again.
+func runEdit(command string, args []string) error {
+	opts, err := parseEditArgs(command, args)
+	if err != nil {
+		return fmt.Errorf("%s\n%s", err, editUsageText(command))
+	}
+
+	contents, err := source.Read(opts.location, source.Options{})
+	if err == source.ErrNotExist {
+		return errors.New(opts.location + " does not exist")
+	} else if err != nil {
+		return err
+	}
+
+	var edited []byte
+	if command == "encrypt" {
+		var recipients []age.Recipient
+		if recipients, err = crypt.ParseRecipients(opts.recipients); err != nil {
+			return err
+		}
+		edited, err = crypt.EncryptValues(contents, opts.paths, recipients)
+	} else {
+		if opts.identity == "" {
+			return errors.New("no age identity given; use --identity or " + identityEnv)
+		}
+		var identities []age.Identity
+		if identities, err = crypt.ReadIdentities(opts.identity); err != nil {
+			return err
+		}
+		edited, _, err = crypt.DecryptValues(contents, opts.paths, identities)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !opts.inPlace {
+		_, err = os.Stdout.Write(edited)
+		return err
+	}
+
+	info, err := os.Stat(opts.location)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(opts.location, edited, info.Mode())
+}
+
+func editUsageText(command string) string {
+	var defaults bytes.Buffer
+	flags := newEditFlagSet(command, &editOptions{})
+	flags.SetOutput(&defaults)
+	flags.PrintDefaults()
+
+	return "yml2env " + command + " [options] <YAML file> [<path>...]\n\nOptions:\n" + defaults.String()
+}
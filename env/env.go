@@ -16,7 +16,7 @@ func Set(key, value string, env []string) []string {
 		}
 	}
 
-	env = append(env[:])
+	env = append([]string{}, env...)
 	if found {
 		env = append(env[:indexOfKey], env[indexOfKey+1:]...)
 	}
@@ -33,3 +33,51 @@ func Get(key, dfault string) (envs string) {
 	}
 	return dfault
 }
+
+// ValidName reports whether name is a portable variable name as defined by
+// POSIX: letters, digits and underscores, not starting with a digit.
+func ValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, r := range name {
+		if !isNameRune(r) || (i == 0 && isDigit(r)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SanitiseName makes name valid by replacing every disallowed character
+// with an underscore, and adding a leading underscore if it starts with a
+// digit.
+func SanitiseName(name string) string {
+	sanitised := []rune(name)
+	for i, r := range sanitised {
+		if !isNameRune(r) {
+			sanitised[i] = '_'
+		}
+	}
+
+	if len(sanitised) == 0 || isDigit(sanitised[0]) {
+		sanitised = append([]rune{'_'}, sanitised...)
+	}
+
+	return string(sanitised)
+}
+
+// Quote makes text a single word for a POSIX shell, quoting it in single
+// quotes and ending the quotes around any it contains.
+func Quote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || isDigit(r) || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
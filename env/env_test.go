@@ -27,4 +27,41 @@ var _ = Describe("env", func() {
 			})
 		})
 	})
+
+	Describe("ValidName", func() {
+		It("accepts POSIX names", func() {
+			Ω(ValidName("CF_API_URL")).Should(BeTrue())
+			Ω(ValidName("_private2")).Should(BeTrue())
+		})
+
+		It("rejects anything else", func() {
+			Ω(ValidName("")).Should(BeFalse())
+			Ω(ValidName("CF-API.URL")).Should(BeFalse())
+			Ω(ValidName("2FA_CODE")).Should(BeFalse())
+		})
+	})
+
+	Describe("Quote", func() {
+		It("quotes text for the shell", func() {
+			Ω(Quote("CF_API_URL=https://api.example.com")).Should(Equal("'CF_API_URL=https://api.example.com'"))
+		})
+
+		It("ends the quotes around single quotes", func() {
+			Ω(Quote("PW=it's $(rm -rf ~)")).Should(Equal(`'PW=it'\''s $(rm -rf ~)'`))
+		})
+	})
+
+	Describe("SanitiseName", func() {
+		It("replaces invalid characters with underscores", func() {
+			Ω(SanitiseName("CF-API.URL")).Should(Equal("CF_API_URL"))
+		})
+
+		It("prefixes names that start with a digit", func() {
+			Ω(SanitiseName("2FA_CODE")).Should(Equal("_2FA_CODE"))
+		})
+
+		It("leaves valid names alone", func() {
+			Ω(SanitiseName("CF_API_URL")).Should(Equal("CF_API_URL"))
+		})
+	})
 })
@@ -0,0 +1,188 @@
+package input
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/EngineerBetter/yml2env/vars"
+	"gopkg.in/yaml.v2"
+)
+
+// Compose resolves the environment of one service in a docker-compose file,
+// the way Compose does: each env_file in order, relative to dir, and then the
+// environment block on top. Variables given without a value take it from the
+// environment of this process, and are left out if it has none. A compose
+// file that is not on disk has no dir, and can only name env files by their
+// absolute paths. The environment values at the paths in decrypted are
+// recorded as sensitive.
+func Compose(composeFile []byte, service, dir string, decrypted []vars.Path) (Document, error) {
+	file, err := YAML(composeFile)
+	if err != nil {
+		return Document{}, err
+	}
+
+	services, _ := fieldMap(file, "services")
+	definition, found := field(services, service)
+	if !found {
+		return Document{}, fmt.Errorf("there is no service %s; services are %s", service, serviceNames(services))
+	}
+	serviceMap, _ := definition.(yaml.MapSlice)
+
+	environment := yaml.MapSlice{}
+
+	envFiles, err := composeEnvFiles(serviceMap)
+	if err != nil {
+		return Document{}, fmt.Errorf("service %s: %s", service, err)
+	}
+	for _, envFile := range envFiles {
+		path := envFile.path
+		if !filepath.IsAbs(path) {
+			if dir == "" {
+				return Document{}, fmt.Errorf("service %s: env_file %s must be an absolute path, as the compose file is not on disk", service, path)
+			}
+			path = filepath.Join(dir, path)
+		}
+
+		contents, err := os.ReadFile(path)
+		if os.IsNotExist(err) && !envFile.required {
+			continue
+		} else if os.IsNotExist(err) {
+			return Document{}, fmt.Errorf("service %s: env_file %s does not exist", service, path)
+		} else if err != nil {
+			return Document{}, fmt.Errorf("service %s: %s", service, err)
+		}
+
+		fileVars, err := Dotenv(contents)
+		if err != nil {
+			return Document{}, fmt.Errorf("service %s: env_file %s: %s", service, path, err)
+		}
+		for _, item := range fileVars {
+			environment = setField(environment, item.Key, item.Value)
+		}
+	}
+
+	block, keys, err := composeEnvironment(serviceMap)
+	if err != nil {
+		return Document{}, fmt.Errorf("service %s: %s", service, err)
+	}
+	for _, item := range block {
+		environment = setField(environment, item.Key, item.Value)
+	}
+
+	document := Document{Vars: environment}
+	for _, path := range beneath(decrypted, "services", service, "environment") {
+		if key, found := keys[path[0]]; found {
+			document.Sensitive = append(document.Sensitive, vars.Path{key})
+		}
+	}
+	return document, nil
+}
+
+type composeEnvFile struct {
+	path     string
+	required bool
+}
+
+// composeEnvFiles reads env_file, which may be a single path, or a list of
+// paths and {path, required} maps.
+func composeEnvFiles(service yaml.MapSlice) ([]composeEnvFile, error) {
+	value, _ := field(service, "env_file")
+
+	var entries []interface{}
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		entries = []interface{}{typed}
+	case []interface{}:
+		entries = typed
+	default:
+		return nil, fmt.Errorf("env_file must be a path or a list")
+	}
+
+	envFiles := make([]composeEnvFile, len(entries))
+	for i, entry := range entries {
+		switch typed := entry.(type) {
+		case string:
+			envFiles[i] = composeEnvFile{path: typed, required: true}
+		case yaml.MapSlice:
+			path, found := field(typed, "path")
+			if !found {
+				return nil, fmt.Errorf("env_file %d has no path", i)
+			}
+			required, set := field(typed, "required")
+			envFiles[i] = composeEnvFile{path: fmt.Sprint(path), required: !set || required == true}
+		default:
+			return nil, fmt.Errorf("env_file %d must be a path or a map", i)
+		}
+	}
+	return envFiles, nil
+}
+
+// composeEnvironment reads environment in either its map form or its list of
+// KEY=value form. It also gives the variable each entry sets by the key or
+// index of the entry.
+func composeEnvironment(service yaml.MapSlice) (yaml.MapSlice, map[string]string, error) {
+	value, _ := field(service, "environment")
+	environment := yaml.MapSlice{}
+	keys := map[string]string{}
+
+	switch typed := value.(type) {
+	case nil:
+	case yaml.MapSlice:
+		for _, item := range typed {
+			key := fmt.Sprint(item.Key)
+			if item.Value == nil {
+				if hostValue, found := os.LookupEnv(key); found {
+					environment = setField(environment, key, hostValue)
+				}
+				continue
+			}
+
+			scalar, ok := vars.Scalar(item.Value)
+			if !ok {
+				return nil, nil, fmt.Errorf("environment %s is not a scalar", key)
+			}
+			environment = setField(environment, key, scalar)
+			keys[key] = key
+		}
+	case []interface{}:
+		for i, entry := range typed {
+			text, ok := vars.Scalar(entry)
+			if !ok {
+				return nil, nil, fmt.Errorf("environment entries must be KEY=value")
+			}
+
+			key, value, found := strings.Cut(text, "=")
+			if !found {
+				if hostValue, found := os.LookupEnv(key); found {
+					environment = setField(environment, key, hostValue)
+				}
+				continue
+			}
+			environment = setField(environment, key, value)
+			keys[strconv.Itoa(i)] = key
+		}
+	default:
+		return nil, nil, fmt.Errorf("environment must be a map or a list")
+	}
+
+	return environment, keys, nil
+}
+
+func serviceNames(services yaml.MapSlice) string {
+	names := make([]string, len(services))
+	for i, item := range services {
+		names[i] = fmt.Sprint(item.Key)
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
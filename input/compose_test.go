@@ -0,0 +1,105 @@
+package input_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/EngineerBetter/yml2env/input"
+	"github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Compose", func() {
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Ω(os.WriteFile(filepath.Join(dir, "common.env"), []byte("A=from common\nB=from common\n"), 0600)).Should(Succeed())
+		Ω(os.WriteFile(filepath.Join(dir, "web.env"), []byte("B=from web\nC=from web\n"), 0600)).Should(Succeed())
+	})
+
+	It("applies env files in order and then the environment map", func() {
+		document, err := Compose([]byte(`
+services:
+  web:
+    env_file:
+    - common.env
+    - path: web.env
+    - path: missing.env
+      required: false
+    environment:
+      C: from environment
+      PORT: 8080
+`), "web", dir, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(document.Vars).Should(Equal(yaml.MapSlice{
+			{Key: "A", Value: "from common"},
+			{Key: "B", Value: "from web"},
+			{Key: "C", Value: "from environment"},
+			{Key: "PORT", Value: "8080"},
+		}))
+	})
+
+	It("reads the list form, taking bare names from this process", func() {
+		GinkgoT().Setenv("FROM_HOST", "host value")
+		os.Unsetenv("NOT_ON_HOST")
+
+		document, err := Compose([]byte(`
+services:
+  web:
+    env_file: common.env
+    environment:
+    - A=x=y
+    - FROM_HOST
+    - NOT_ON_HOST
+`), "web", dir, nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(document.Vars).Should(Equal(yaml.MapSlice{
+			{Key: "A", Value: "x=y"},
+			{Key: "B", Value: "from common"},
+			{Key: "FROM_HOST", Value: "host value"},
+		}))
+	})
+
+	It("records the environment values that were decrypted as sensitive", func() {
+		document, err := Compose([]byte(`
+services:
+  web:
+    environment:
+      PASSWORD: hunter2
+      USER: admin
+  worker:
+    environment:
+    - USER=admin
+    - TOKEN=secret
+`), "worker", dir, []vars.Path{{"services", "web", "environment", "PASSWORD"}, {"services", "worker", "environment", "1"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(document.Sensitive).Should(Equal([]vars.Path{{"TOKEN"}}))
+	})
+
+	It("requires env files unless they are marked optional", func() {
+		_, err := Compose([]byte("services:\n  web:\n    env_file: missing.env\n"), "web", dir, nil)
+		Ω(err).Should(MatchError("service web: env_file " + filepath.Join(dir, "missing.env") + " does not exist"))
+	})
+
+	It("reads env files from disk, whatever their names look like", func() {
+		_, err := Compose([]byte("services:\n  web:\n    env_file: [\"-\", \"vault://secret/app\"]\n"), "web", dir, nil)
+		Ω(err).Should(MatchError("service web: env_file " + filepath.Join(dir, "-") + " does not exist"))
+	})
+
+	It("only allows absolute env files without a directory", func() {
+		_, err := Compose([]byte("services:\n  web:\n    env_file: common.env\n"), "web", "", nil)
+		Ω(err).Should(MatchError("service web: env_file common.env must be an absolute path, as the compose file is not on disk"))
+
+		document, err := Compose([]byte("services:\n  web:\n    env_file: "+filepath.Join(dir, "common.env")+"\n"), "web", "", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(document.Vars).Should(HaveLen(2))
+	})
+
+	It("names the services when the one asked for is missing", func() {
+		_, err := Compose([]byte("services:\n  web: {}\n  db: {}\n"), "worker", dir, nil)
+		Ω(err).Should(MatchError("there is no service worker; services are db, web"))
+	})
+})
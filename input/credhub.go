@@ -0,0 +1,104 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/EngineerBetter/yml2env/env"
+	"github.com/EngineerBetter/yml2env/vars"
+	"gopkg.in/yaml.v2"
+)
+
+// CredHub parses the YAML read by credhub import and written by credhub
+// export. Each credential path becomes a variable name, with its slashes
+// and any other characters not allowed in names replaced by underscores.
+// The fields of certificate, ssh, rsa and user credentials become variables
+// suffixed with the field name, such as _CERTIFICATE and _PRIVATE_KEY, and
+// json credentials are exported as JSON. Every value is recorded as
+// sensitive.
+func CredHub(source []byte) (Document, error) {
+	file, err := YAML(source)
+	if err != nil {
+		return Document{}, err
+	}
+
+	value, _ := field(file, "credentials")
+	credentials, ok := value.([]interface{})
+	if !ok {
+		return Document{}, fmt.Errorf("credentials must be a list")
+	}
+
+	document := Document{Vars: yaml.MapSlice{}}
+	paths := map[string]string{}
+
+	add := func(path, key string, value interface{}) error {
+		if previous, found := paths[key]; found {
+			return fmt.Errorf("credentials %s and %s both become %s", previous, path, key)
+		}
+		paths[key] = path
+		document.Vars = append(document.Vars, yaml.MapItem{Key: key, Value: value})
+		document.Sensitive = append(document.Sensitive, vars.Path{key})
+		return nil
+	}
+
+	for i, entry := range credentials {
+		credential, ok := entry.(yaml.MapSlice)
+		if !ok {
+			return Document{}, fmt.Errorf("credential %d is not a map", i)
+		}
+
+		nameValue, _ := field(credential, "name")
+		path, ok := nameValue.(string)
+		if !ok || path == "" {
+			return Document{}, fmt.Errorf("credential %d has no name", i)
+		}
+		credentialType, _ := field(credential, "type")
+		value, found := field(credential, "value")
+		if !found {
+			return Document{}, fmt.Errorf("%s has no value", path)
+		}
+		key := credentialName(path)
+
+		switch credentialType {
+		case "value", "password":
+			scalar, ok := vars.Scalar(value)
+			if !ok {
+				return Document{}, fmt.Errorf("%s is a %s, but its value is not a scalar", path, credentialType)
+			}
+			err = add(path, key, scalar)
+		case "json":
+			var encoded string
+			if encoded, err = vars.JSON(value); err == nil {
+				err = add(path, key, encoded)
+			}
+		case "certificate", "ssh", "rsa", "user":
+			fields, ok := value.(yaml.MapSlice)
+			if !ok {
+				return Document{}, fmt.Errorf("%s is a %s, but its value is not a map", path, credentialType)
+			}
+			for _, item := range fields {
+				scalar, ok := vars.Scalar(item.Value)
+				if !ok {
+					return Document{}, fmt.Errorf("%s has a %v that is not a scalar", path, item.Key)
+				}
+				if err = add(path, key+"_"+credentialName(fmt.Sprint(item.Key)), scalar); err != nil {
+					break
+				}
+			}
+		default:
+			return Document{}, fmt.Errorf("%s has unknown type %v", path, credentialType)
+		}
+
+		if err != nil {
+			return Document{}, err
+		}
+	}
+
+	return document, nil
+}
+
+// credentialName turns a credential path such as /concourse/main/db-password
+// into concourse_main_db_password.
+func credentialName(path string) string {
+	return env.SanitiseName(strings.ReplaceAll(strings.Trim(path, "/"), "/", "_"))
+}
@@ -0,0 +1,62 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	"github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("CredHub", func() {
+	It("names each credential after its path and expands structured values", func() {
+		document, err := CredHub([]byte(`credentials:
+- name: /concourse/main/db-password
+  type: password
+  value: hunter2
+- name: /concourse/main/tls
+  type: certificate
+  value:
+    ca: CA
+    certificate: CERT
+    private_key: KEY
+- name: /concourse/main/admin
+  type: user
+  value:
+    username: admin
+    password: secret
+- name: settings
+  type: json
+  value:
+    replicas: 2
+`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(document.Vars).Should(Equal(yaml.MapSlice{
+			{Key: "concourse_main_db_password", Value: "hunter2"},
+			{Key: "concourse_main_tls_ca", Value: "CA"},
+			{Key: "concourse_main_tls_certificate", Value: "CERT"},
+			{Key: "concourse_main_tls_private_key", Value: "KEY"},
+			{Key: "concourse_main_admin_username", Value: "admin"},
+			{Key: "concourse_main_admin_password", Value: "secret"},
+			{Key: "settings", Value: `{"replicas":2}`},
+		}))
+		Ω(document.Sensitive).Should(ContainElement(vars.Path{"concourse_main_db_password"}))
+		Ω(document.Sensitive).Should(HaveLen(7))
+	})
+
+	It("rejects credentials that become the same name", func() {
+		_, err := CredHub([]byte(`credentials:
+- {name: /a/b, type: value, value: x}
+- {name: /a-b, type: value, value: y}
+`))
+		Ω(err).Should(MatchError("credentials /a/b and /a-b both become a_b"))
+	})
+
+	It("rejects unknown types and mismatched values", func() {
+		_, err := CredHub([]byte("credentials:\n- {name: /a, type: magic, value: x}\n"))
+		Ω(err).Should(MatchError("/a has unknown type magic"))
+
+		_, err = CredHub([]byte("credentials:\n- {name: /a, type: ssh, value: x}\n"))
+		Ω(err).Should(MatchError("/a is a ssh, but its value is not a map"))
+	})
+})
@@ -0,0 +1,92 @@
+package input
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DocumentSelector picks documents out of a multi-document stream.
+type DocumentSelector struct {
+	// All selects every document, to be merged in order.
+	All bool
+	// Index selects a single document by its position, starting at zero.
+	Index int
+	// Key and Value select the single document whose top-level Key has
+	// Value, or for a Key of name, a named document called Value.
+	Key   string
+	Value string
+}
+
+// ParseDocumentSelector reads "all", an index such as "2", or a
+// discriminator such as "name=staging".
+func ParseDocumentSelector(expression string) (DocumentSelector, error) {
+	if expression == "all" {
+		return DocumentSelector{All: true}, nil
+	}
+
+	if key, value, found := strings.Cut(expression, "="); found {
+		if key == "" {
+			return DocumentSelector{}, fmt.Errorf("document selector %q has no key", expression)
+		}
+		return DocumentSelector{Key: key, Value: value}, nil
+	}
+
+	index, err := strconv.Atoi(expression)
+	if err != nil || index < 0 {
+		return DocumentSelector{}, fmt.Errorf("document selector %q is not all, an index or key=value", expression)
+	}
+	return DocumentSelector{Index: index}, nil
+}
+
+// Select returns the chosen documents, along with the index of each.
+func (s DocumentSelector) Select(documents []Document) ([]Document, []int, error) {
+	if s.All {
+		indexes := make([]int, len(documents))
+		for i := range documents {
+			indexes[i] = i
+		}
+		return documents, indexes, nil
+	}
+
+	if s.Key == "" {
+		if s.Index >= len(documents) {
+			return nil, nil, fmt.Errorf("there is no document %d, only %d", s.Index, len(documents))
+		}
+		return documents[s.Index : s.Index+1], []int{s.Index}, nil
+	}
+
+	found := -1
+	for i, document := range documents {
+		if !s.matches(document) {
+			continue
+		}
+		if found != -1 {
+			return nil, nil, fmt.Errorf("documents %d and %d both have %s=%s", found, i, s.Key, s.Value)
+		}
+		found = i
+	}
+
+	if found == -1 {
+		return nil, nil, fmt.Errorf("no document has %s=%s", s.Key, s.Value)
+	}
+	return documents[found : found+1], []int{found}, nil
+}
+
+func (s DocumentSelector) matches(document Document) bool {
+	if s.Key == "name" && document.Name != "" {
+		return document.Name == s.Value
+	}
+	return hasValue(document.Vars, s.Key, s.Value)
+}
+
+func hasValue(document yaml.MapSlice, key, value string) bool {
+	for _, item := range document {
+		if fmt.Sprint(item.Key) == key {
+			return fmt.Sprint(item.Value) == value
+		}
+	}
+	return false
+}
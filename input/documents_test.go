@@ -0,0 +1,80 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("DocumentSelector", func() {
+	documents := []Document{
+		{Vars: yaml.MapSlice{{Key: "name", Value: "staging"}, {Key: "a", Value: 1}}},
+		{Vars: yaml.MapSlice{{Key: "name", Value: "production"}, {Key: "a", Value: 2}}},
+	}
+
+	DescribeTable("parsing",
+		func(expression string, expected DocumentSelector) {
+			selector, err := ParseDocumentSelector(expression)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(selector).Should(Equal(expected))
+		},
+		Entry("all", "all", DocumentSelector{All: true}),
+		Entry("index", "2", DocumentSelector{Index: 2}),
+		Entry("discriminator", "name=staging", DocumentSelector{Key: "name", Value: "staging"}),
+	)
+
+	It("rejects anything else", func() {
+		_, err := ParseDocumentSelector("first")
+		Ω(err).Should(HaveOccurred())
+		_, err = ParseDocumentSelector("-1")
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("selects every document", func() {
+		selected, indexes, err := DocumentSelector{All: true}.Select(documents)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(selected).Should(Equal(documents))
+		Ω(indexes).Should(Equal([]int{0, 1}))
+	})
+
+	It("selects a document by index", func() {
+		selected, indexes, err := DocumentSelector{Index: 1}.Select(documents)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(selected).Should(Equal(documents[1:]))
+		Ω(indexes).Should(Equal([]int{1}))
+	})
+
+	It("selects a document by discriminator", func() {
+		selected, _, err := DocumentSelector{Key: "name", Value: "staging"}.Select(documents)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(selected).Should(Equal(documents[:1]))
+	})
+
+	It("selects a named document by name rather than by its vars", func() {
+		named := []Document{
+			{Name: "app", Vars: yaml.MapSlice{{Key: "name", Value: "settings"}}},
+			{Name: "settings", Vars: yaml.MapSlice{{Key: "a", Value: 1}}},
+		}
+		selected, indexes, err := DocumentSelector{Key: "name", Value: "settings"}.Select(named)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(selected).Should(Equal(named[1:]))
+		Ω(indexes).Should(Equal([]int{1}))
+	})
+
+	It("fails when nothing matches", func() {
+		_, _, err := DocumentSelector{Index: 2}.Select(documents)
+		Ω(err).Should(MatchError("there is no document 2, only 2"))
+
+		_, _, err = DocumentSelector{Key: "name", Value: "dev"}.Select(documents)
+		Ω(err).Should(MatchError("no document has name=dev"))
+	})
+
+	It("fails when more than one document matches", func() {
+		_, _, err := DocumentSelector{Key: "name", Value: "x"}.Select([]Document{
+			{Vars: yaml.MapSlice{{Key: "name", Value: "x"}}},
+			{Vars: yaml.MapSlice{{Key: "name", Value: "x"}}},
+		})
+		Ω(err).Should(MatchError("documents 0 and 1 both have name=x"))
+	})
+})
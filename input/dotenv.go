@@ -0,0 +1,197 @@
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Dotenv parses a .env file of KEY=value lines into an ordered map. Lines
+// may start with export, and values may be unquoted, single-quoted and
+// taken literally, or double-quoted with backslash escapes and spanning
+// several lines. Comments start with # at the start of a line, or after
+// whitespace following a value.
+func Dotenv(source []byte) (yaml.MapSlice, error) {
+	p := dotenvParser{source: []rune(string(source)), line: 1}
+	mapSlice := yaml.MapSlice{}
+
+	for {
+		p.skipBlankLinesAndComments()
+		if p.done() {
+			return mapSlice, nil
+		}
+
+		line := p.line
+		key, value, err := p.entry()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", line, err)
+		}
+		mapSlice = append(mapSlice, yaml.MapItem{Key: key, Value: value})
+	}
+}
+
+type dotenvParser struct {
+	source   []rune
+	position int
+	line     int
+}
+
+func (p *dotenvParser) done() bool {
+	return p.position >= len(p.source)
+}
+
+func (p *dotenvParser) peek() rune {
+	return p.source[p.position]
+}
+
+func (p *dotenvParser) next() rune {
+	r := p.source[p.position]
+	p.position++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+func (p *dotenvParser) skipSpaces() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) skipRestOfLine() {
+	for !p.done() && p.next() != '\n' {
+	}
+}
+
+func (p *dotenvParser) skipBlankLinesAndComments() {
+	for !p.done() {
+		p.skipSpaces()
+		if p.done() {
+			return
+		}
+
+		switch p.peek() {
+		case '#':
+			p.skipRestOfLine()
+		case '\r', '\n':
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+func (p *dotenvParser) entry() (string, string, error) {
+	key := p.word()
+	if key == "export" {
+		p.skipSpaces()
+		if !p.done() && p.peek() != '=' {
+			key = p.word()
+		}
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("expected KEY=value")
+	}
+
+	p.skipSpaces()
+	if p.done() || p.next() != '=' {
+		return "", "", fmt.Errorf("expected = after %s", key)
+	}
+	p.skipSpaces()
+
+	value, err := p.value()
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %s", key, err)
+	}
+	return key, value, nil
+}
+
+func (p *dotenvParser) word() string {
+	start := p.position
+	for !p.done() && !strings.ContainsRune(" \t\r\n=", p.peek()) {
+		p.next()
+	}
+	return string(p.source[start:p.position])
+}
+
+func (p *dotenvParser) value() (string, error) {
+	if p.done() {
+		return "", nil
+	}
+
+	switch p.peek() {
+	case '\'':
+		p.next()
+		return p.quoted('\'', false)
+	case '"':
+		p.next()
+		return p.quoted('"', true)
+	}
+
+	var value strings.Builder
+	afterSpace := true
+	for !p.done() && p.peek() != '\n' {
+		r := p.next()
+		if r == '#' && afterSpace {
+			p.skipRestOfLine()
+			break
+		}
+		value.WriteRune(r)
+		afterSpace = r == ' ' || r == '\t'
+	}
+	return strings.TrimSpace(value.String()), nil
+}
+
+func (p *dotenvParser) quoted(quote rune, escapes bool) (string, error) {
+	var value strings.Builder
+
+	for {
+		if p.done() {
+			return "", fmt.Errorf("unterminated %c quoted value", quote)
+		}
+
+		r := p.next()
+		switch {
+		case r == quote:
+			return value.String(), p.endOfValue()
+		case r == '\\' && escapes && !p.done():
+			value.WriteString(unescape(p.next()))
+		default:
+			value.WriteRune(r)
+		}
+	}
+}
+
+func unescape(r rune) string {
+	switch r {
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 't':
+		return "\t"
+	case '"', '\\', '$', '\'':
+		return string(r)
+	}
+	return "\\" + string(r)
+}
+
+// endOfValue allows only whitespace and a comment after a quoted value.
+func (p *dotenvParser) endOfValue() error {
+	p.skipSpaces()
+	if p.done() {
+		return nil
+	}
+
+	switch p.peek() {
+	case '#':
+		p.skipRestOfLine()
+	case '\r', '\n':
+		p.next()
+	default:
+		return fmt.Errorf("unexpected %q after quoted value", p.peek())
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Dotenv", func() {
+	It("parses each kind of line", func() {
+		mapSlice, err := Dotenv([]byte(`# a comment
+PLAIN=value
+export EXPORTED=yes
+SPACED = padded value  # trailing comment
+HASH=no#comment
+SINGLE='literal \n $HOME'
+DOUBLE="tab\there \"quoted\""
+MULTI="first
+second"
+EMPTY=
+
+CRLF=value` + "\r\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "PLAIN", Value: "value"},
+			{Key: "EXPORTED", Value: "yes"},
+			{Key: "SPACED", Value: "padded value"},
+			{Key: "HASH", Value: "no#comment"},
+			{Key: "SINGLE", Value: `literal \n $HOME`},
+			{Key: "DOUBLE", Value: "tab\there \"quoted\""},
+			{Key: "MULTI", Value: "first\nsecond"},
+			{Key: "EMPTY", Value: ""},
+			{Key: "CRLF", Value: "value"},
+		}))
+	})
+
+	It("accepts a key named export", func() {
+		mapSlice, err := Dotenv([]byte("export=1\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{{Key: "export", Value: "1"}}))
+	})
+
+	It("reports the line of malformed entries", func() {
+		_, err := Dotenv([]byte("A=1\nB\n"))
+		Ω(err).Should(MatchError("line 2: expected = after B"))
+
+		_, err = Dotenv([]byte("A=1\nB=\"open\n"))
+		Ω(err).Should(MatchError(`line 2: B: unterminated " quoted value`))
+
+		_, err = Dotenv([]byte("A='x' y\n"))
+		Ω(err).Should(MatchError(`line 1: A: unexpected 'y' after quoted value`))
+	})
+})
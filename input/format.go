@@ -0,0 +1,159 @@
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/EngineerBetter/yml2env/vars"
+	"gopkg.in/yaml.v2"
+)
+
+// Format is a vars file format.
+type Format string
+
+const (
+	// FormatAuto picks a format with Detect.
+	FormatAuto Format = "auto"
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+	// FormatTerraform is the JSON written by terraform output -json.
+	FormatTerraform  Format = "terraform"
+	FormatDotenv     Format = "dotenv"
+	FormatTOML       Format = "toml"
+	FormatINI        Format = "ini"
+	FormatProperties Format = "properties"
+	// FormatTfvars is a Terraform variable definitions file.
+	FormatTfvars Format = "tfvars"
+	// FormatKubernetes is a stream of Secret and ConfigMap manifests.
+	FormatKubernetes Format = "kubernetes"
+	// FormatCredHub is the YAML read by credhub import.
+	FormatCredHub Format = "credhub"
+)
+
+// ParseFormat validates the name of a Format.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatAuto, FormatYAML, FormatJSON, FormatTerraform, FormatDotenv, FormatTOML, FormatINI, FormatProperties, FormatTfvars, FormatKubernetes, FormatCredHub:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q", name)
+}
+
+// Detect picks a format from the name of location, or failing that from the
+// content: anything that starts with { is JSON. YAML with a top-level kind of
+// Secret or ConfigMap is read as Kubernetes manifests.
+func Detect(location string, source []byte) Format {
+	base := strings.ToLower(filepath.Base(location))
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return FormatDotenv
+	}
+
+	switch filepath.Ext(base) {
+	case ".env":
+		return FormatDotenv
+	case ".toml":
+		return FormatTOML
+	case ".ini":
+		return FormatINI
+	case ".properties":
+		return FormatProperties
+	case ".tfvars":
+		return FormatTfvars
+	case ".json":
+		return FormatJSON
+	case ".yml", ".yaml":
+		return yamlOrKubernetes(source)
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(source), []byte("{")) {
+		return FormatJSON
+	}
+	return yamlOrKubernetes(source)
+}
+
+func yamlOrKubernetes(source []byte) Format {
+	if manifestKind.Match(source) {
+		return FormatKubernetes
+	}
+	return FormatYAML
+}
+
+// Document is a single parsed vars document.
+type Document struct {
+	// Name identifies the document for --document name=..., such as the
+	// metadata.name of a Kubernetes manifest.
+	Name string
+	Vars yaml.MapSlice
+	// Sensitive holds the paths of values that must not be shown in
+	// diagnostic output.
+	Sensitive []vars.Path
+}
+
+// Parse reads every document in source. With raw set, YAML scalars keep the
+// literal text they were written with. Decrypted holds the paths of the
+// values that were decrypted in each document of a YAML stream, which are
+// recorded as sensitive wherever the format puts them.
+func Parse(format Format, source []byte, raw bool, decrypted [][]vars.Path) ([]Document, error) {
+	switch format {
+	case FormatJSON:
+		return single(JSON(source))
+	case FormatTerraform:
+		document, err := Terraform(source)
+		if err != nil {
+			return nil, err
+		}
+		return []Document{document}, nil
+	case FormatDotenv:
+		return single(Dotenv(source))
+	case FormatTOML:
+		return single(TOML(source))
+	case FormatINI:
+		return single(INI(source))
+	case FormatProperties:
+		return single(Properties(source))
+	case FormatTfvars:
+		return single(Tfvars(source))
+	case FormatKubernetes:
+		return Kubernetes(source, decrypted)
+	case FormatCredHub:
+		document, err := CredHub(source)
+		if err != nil {
+			return nil, err
+		}
+		return []Document{document}, nil
+	case FormatYAML:
+		parse := YAMLDocuments
+		if raw {
+			parse = RawYAMLDocuments
+		}
+		mapSlices, err := parse(source)
+		if err != nil {
+			return nil, err
+		}
+		documents := documents(mapSlices)
+		for i := range documents {
+			if i < len(decrypted) {
+				documents[i].Sensitive = decrypted[i]
+			}
+		}
+		return documents, nil
+	}
+	return nil, fmt.Errorf("cannot parse %s", format)
+}
+
+func single(mapSlice yaml.MapSlice, err error) ([]Document, error) {
+	if err != nil {
+		return nil, err
+	}
+	return []Document{{Vars: mapSlice}}, nil
+}
+
+func documents(mapSlices []yaml.MapSlice) []Document {
+	documents := make([]Document, len(mapSlices))
+	for i, mapSlice := range mapSlices {
+		documents[i] = Document{Vars: mapSlice}
+	}
+	return documents
+}
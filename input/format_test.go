@@ -0,0 +1,38 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Detect", func() {
+	It("goes by the file extension", func() {
+		Ω(Detect("vars.json", []byte("a: 1"))).Should(Equal(FormatJSON))
+		Ω(Detect("vars.yml", []byte("{}"))).Should(Equal(FormatYAML))
+	})
+
+	It("recognises dotenv files by name", func() {
+		Ω(Detect("ci/.env", []byte("A=1"))).Should(Equal(FormatDotenv))
+		Ω(Detect(".env.local", []byte("A=1"))).Should(Equal(FormatDotenv))
+		Ω(Detect("local.env", []byte("A=1"))).Should(Equal(FormatDotenv))
+	})
+
+	It("recognises other formats by extension", func() {
+		Ω(Detect("config.toml", nil)).Should(Equal(FormatTOML))
+		Ω(Detect("config.ini", nil)).Should(Equal(FormatINI))
+		Ω(Detect("application.properties", nil)).Should(Equal(FormatProperties))
+		Ω(Detect("terraform.tfvars", nil)).Should(Equal(FormatTfvars))
+	})
+
+	It("recognises Kubernetes Secret and ConfigMap manifests", func() {
+		Ω(Detect("secret.yml", []byte("apiVersion: v1\nkind: Secret\n"))).Should(Equal(FormatKubernetes))
+		Ω(Detect("-", []byte("kind: \"ConfigMap\"\n"))).Should(Equal(FormatKubernetes))
+		Ω(Detect("deployment.yml", []byte("kind: Deployment\nspec:\n  kind: Secret\n"))).Should(Equal(FormatYAML))
+	})
+
+	It("falls back to the content", func() {
+		Ω(Detect("-", []byte("  {\"a\": 1}"))).Should(Equal(FormatJSON))
+		Ω(Detect("/dev/fd/3", []byte("a: 1"))).Should(Equal(FormatYAML))
+	})
+})
@@ -0,0 +1,67 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// INI parses an INI file into an ordered map. Keys before the first section
+// are top-level, and each [section] becomes a nested map, so that sections
+// flatten in the same way as nested YAML. Keys and values are separated by =
+// or :, values may be quoted, and lines starting with ; or # are comments.
+func INI(source []byte) (yaml.MapSlice, error) {
+	root := yaml.MapSlice{}
+	section := -1
+	sections := map[string]int{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(source))
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "["):
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section name", number)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+
+			index, found := sections[name]
+			if !found {
+				index = len(root)
+				sections[name] = index
+				root = append(root, yaml.MapItem{Key: name, Value: yaml.MapSlice{}})
+			}
+			section = index
+		default:
+			separator := strings.IndexAny(line, "=:")
+			if separator == -1 {
+				return nil, fmt.Errorf("line %d: expected key = value", number)
+			}
+			item := yaml.MapItem{
+				Key:   strings.TrimSpace(line[:separator]),
+				Value: unquote(strings.TrimSpace(line[separator+1:])),
+			}
+
+			if section == -1 {
+				root = append(root, item)
+			} else {
+				root[section].Value = append(root[section].Value.(yaml.MapSlice), item)
+			}
+		}
+	}
+
+	return root, scanner.Err()
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,45 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("INI", func() {
+	It("nests sections", func() {
+		mapSlice, err := INI([]byte(`; comment
+name = app
+
+[database]
+host = db.example.com
+# another comment
+password: "quoted value"
+
+[cache]
+host = cache.example.com
+
+[database]
+port = 5432
+`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "name", Value: "app"},
+			{Key: "database", Value: yaml.MapSlice{
+				{Key: "host", Value: "db.example.com"},
+				{Key: "password", Value: "quoted value"},
+				{Key: "port", Value: "5432"},
+			}},
+			{Key: "cache", Value: yaml.MapSlice{{Key: "host", Value: "cache.example.com"}}},
+		}))
+	})
+
+	It("reports the line of malformed entries", func() {
+		_, err := INI([]byte("[ok]\n[broken\n"))
+		Ω(err).Should(MatchError("line 2: unterminated section name"))
+
+		_, err = INI([]byte("a = 1\nnonsense\n"))
+		Ω(err).Should(MatchError("line 2: expected key = value"))
+	})
+})
@@ -0,0 +1,72 @@
+package input
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// JSON parses a JSON object into an ordered map. Numbers are kept as
+// json.Number so that their exact text survives, rather than being rounded
+// through float64 as they would be if parsed as YAML.
+func JSON(source []byte) (yaml.MapSlice, error) {
+	decoder := json.NewDecoder(bytes.NewReader(source))
+	decoder.UseNumber()
+
+	value, err := decodeJSON(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after the top-level object")
+	}
+
+	mapSlice, ok := value.(yaml.MapSlice)
+	if !ok {
+		return nil, errors.New("document is not an object")
+	}
+	return mapSlice, nil
+}
+
+func decodeJSON(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		mapSlice := yaml.MapSlice{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(decoder)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %s", key, err)
+			}
+			mapSlice = append(mapSlice, yaml.MapItem{Key: key, Value: value})
+		}
+		_, err := decoder.Token()
+		return mapSlice, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := decoder.Token()
+		return list, err
+	}
+
+	return token, nil
+}
@@ -0,0 +1,41 @@
+package input_test
+
+import (
+	"encoding/json"
+
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("JSON", func() {
+	It("keeps key order and nesting", func() {
+		mapSlice, err := JSON([]byte(`{"b": "x", "a": {"c": [true, null]}}`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "b", Value: "x"},
+			{Key: "a", Value: yaml.MapSlice{{Key: "c", Value: []interface{}{true, nil}}}},
+		}))
+	})
+
+	It("keeps the exact text of numbers", func() {
+		mapSlice, err := JSON([]byte(`{"version": 1.10, "big": 123456789012345678901234567890}`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "version", Value: json.Number("1.10")},
+			{Key: "big", Value: json.Number("123456789012345678901234567890")},
+		}))
+	})
+
+	It("rejects anything but a single object", func() {
+		_, err := JSON([]byte(`[1]`))
+		Ω(err).Should(MatchError("document is not an object"))
+
+		_, err = JSON([]byte(`{} {}`))
+		Ω(err).Should(HaveOccurred())
+
+		_, err = JSON([]byte(`{"a": }`))
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,62 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// CheckKeys rejects YAML in which any map repeats a key, or has two keys
+// that become the same once passed through normalise, giving the line
+// numbers of both entries.
+func CheckKeys(source []byte, normalise func(string) string) error {
+	decoder := yamlv3.NewDecoder(bytes.NewReader(source))
+
+	for {
+		var document yamlv3.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := checkNode(&document, normalise); err != nil {
+			return err
+		}
+	}
+}
+
+func checkNode(node *yamlv3.Node, normalise func(string) string) error {
+	if node.Kind == yamlv3.MappingNode {
+		seen := map[string]*yamlv3.Node{}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind != yamlv3.ScalarNode || key.Tag == "!!merge" {
+				continue
+			}
+
+			normalised := normalise(key.Value)
+			if previous, found := seen[normalised]; found {
+				if previous.Value == key.Value {
+					return fmt.Errorf("duplicate key %q on lines %d and %d", key.Value, previous.Line, key.Line)
+				}
+				return fmt.Errorf("keys %q on line %d and %q on line %d both become %s", previous.Value, previous.Line, key.Value, key.Line, normalised)
+			}
+			seen[normalised] = key
+		}
+	}
+
+	for _, child := range node.Content {
+		if err := checkNode(child, normalise); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package input_test
+
+import (
+	"strings"
+
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckKeys", func() {
+	It("accepts unique keys", func() {
+		Ω(CheckKeys([]byte("a: 1\nb:\n  a: 2\n"), strings.ToUpper)).Should(Succeed())
+	})
+
+	It("reports duplicate keys with their line numbers", func() {
+		err := CheckKeys([]byte("a: 1\nb: 2\na: 3\n"), strings.ToUpper)
+		Ω(err).Should(MatchError(`duplicate key "a" on lines 1 and 3`))
+	})
+
+	It("reports keys that collide once normalised", func() {
+		err := CheckKeys([]byte("db:\n  host: x\n  HOST: y\n"), strings.ToUpper)
+		Ω(err).Should(MatchError(`keys "host" on line 2 and "HOST" on line 3 both become HOST`))
+	})
+
+	It("compares keys as they are written, not as YAML reads them", func() {
+		Ω(CheckKeys([]byte("yes: a\non: b\n"), strings.ToUpper)).Should(Succeed())
+
+		err := CheckKeys([]byte("1: a\n\"1\": b\n"), strings.ToUpper)
+		Ω(err).Should(MatchError(`duplicate key "1" on lines 1 and 2`))
+	})
+
+	It("checks every document in a stream", func() {
+		err := CheckKeys([]byte("a: 1\n---\nb: 1\nb: 2\n"), strings.ToUpper)
+		Ω(err).Should(MatchError(`duplicate key "b" on lines 3 and 4`))
+	})
+})
@@ -0,0 +1,137 @@
+package input
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/EngineerBetter/yml2env/vars"
+	"gopkg.in/yaml.v2"
+)
+
+var manifestKind = regexp.MustCompile(`(?m)^kind:[ \t]*["']?(Secret|ConfigMap)["']?[ \t]*$`)
+
+// Kubernetes reads the Secret and ConfigMap manifests in a YAML stream,
+// including the items of a List, skipping manifests of any other kind. Each
+// becomes a document named after its metadata.name. Secret data and
+// ConfigMap binaryData are base64-decoded, and stringData and ConfigMap data
+// are taken as written; stringData wins over data, as it does in Kubernetes.
+// Every value of a Secret is recorded as sensitive, as are the ConfigMap
+// values at the paths decrypted in each manifest.
+func Kubernetes(source []byte, decrypted [][]vars.Path) ([]Document, error) {
+	manifests, err := YAMLDocuments(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents []Document
+	for i, manifest := range manifests {
+		var paths []vars.Path
+		if i < len(decrypted) {
+			paths = decrypted[i]
+		}
+
+		if kind, _ := field(manifest, "kind"); kind == "List" {
+			items, _ := field(manifest, "items")
+			list, ok := items.([]interface{})
+			if !ok {
+				return nil, errors.New("List has no items")
+			}
+			for j, item := range list {
+				itemManifest, ok := item.(yaml.MapSlice)
+				if !ok {
+					return nil, errors.New("List items must be manifests")
+				}
+				if documents, err = appendManifest(documents, itemManifest, beneath(paths, "items", strconv.Itoa(j))); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if documents, err = appendManifest(documents, manifest, paths); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(documents) == 0 {
+		return nil, errors.New("there are no Secret or ConfigMap manifests")
+	}
+	return documents, nil
+}
+
+func appendManifest(documents []Document, manifest yaml.MapSlice, decrypted []vars.Path) ([]Document, error) {
+	kind, _ := field(manifest, "kind")
+	if kind != "Secret" && kind != "ConfigMap" {
+		return documents, nil
+	}
+
+	name := ""
+	if metadata, ok := fieldMap(manifest, "metadata"); ok {
+		if value, found := field(metadata, "name"); found {
+			name = fmt.Sprint(value)
+		}
+	}
+
+	decoded, verbatim := "binaryData", "data"
+	if kind == "Secret" {
+		decoded, verbatim = "data", "stringData"
+	}
+
+	document := Document{Name: name, Vars: yaml.MapSlice{}}
+	if data, ok := fieldMap(manifest, decoded); ok {
+		for _, item := range data {
+			encoded, _ := vars.Scalar(item.Value)
+			value, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %s.%v is not base64", kind, name, decoded, item.Key)
+			}
+			document.Vars = setField(document.Vars, item.Key, string(value))
+		}
+	}
+	if data, ok := fieldMap(manifest, verbatim); ok {
+		for _, item := range data {
+			document.Vars = setField(document.Vars, item.Key, item.Value)
+		}
+	}
+
+	if kind == "Secret" {
+		for _, item := range document.Vars {
+			document.Sensitive = append(document.Sensitive, vars.Path{fmt.Sprint(item.Key)})
+		}
+	} else {
+		document.Sensitive = append(beneath(decrypted, decoded), beneath(decrypted, verbatim)...)
+	}
+
+	return append(documents, document), nil
+}
+
+// beneath gives the paths within prefix, relative to it.
+func beneath(paths []vars.Path, prefix ...string) []vars.Path {
+	var relative []vars.Path
+	for _, path := range paths {
+		if len(path) > len(prefix) && strings.Join(path[:len(prefix)], "\x00") == strings.Join(prefix, "\x00") {
+			relative = append(relative, path[len(prefix):])
+		}
+	}
+	return relative
+}
+
+func fieldMap(mapSlice yaml.MapSlice, key string) (yaml.MapSlice, bool) {
+	value, _ := field(mapSlice, key)
+	nested, ok := value.(yaml.MapSlice)
+	return nested, ok
+}
+
+func setField(mapSlice yaml.MapSlice, key, value interface{}) yaml.MapSlice {
+	for i, item := range mapSlice {
+		if item.Key == key {
+			mapSlice[i].Value = value
+			return mapSlice
+		}
+	}
+	return append(mapSlice, yaml.MapItem{Key: key, Value: value})
+}
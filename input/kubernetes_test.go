@@ -0,0 +1,116 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	"github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Kubernetes", func() {
+	It("decodes Secret data and takes stringData as written", func() {
+		documents, err := Kubernetes([]byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+data:
+  db-password: aHVudGVyMg==
+  token: b2xk
+stringData:
+  token: new
+`), nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents).Should(Equal([]Document{{
+			Name: "app",
+			Vars: yaml.MapSlice{
+				{Key: "db-password", Value: "hunter2"},
+				{Key: "token", Value: "new"},
+			},
+			Sensitive: []vars.Path{{"db-password"}, {"token"}},
+		}}))
+	})
+
+	It("reads ConfigMaps and skips other kinds", func() {
+		documents, err := Kubernetes([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  LOG_LEVEL: debug
+binaryData:
+  banner: aGk=
+`), nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents).Should(Equal([]Document{{
+			Name: "settings",
+			Vars: yaml.MapSlice{
+				{Key: "banner", Value: "hi"},
+				{Key: "LOG_LEVEL", Value: "debug"},
+			},
+		}}))
+	})
+
+	It("reads the items of a List", func() {
+		documents, err := Kubernetes([]byte(`apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: one
+  data:
+    A: a
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: two
+  data:
+    B: b
+`), nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents).Should(HaveLen(2))
+		Ω(documents[1].Name).Should(Equal("two"))
+	})
+
+	It("records the ConfigMap values that were decrypted as sensitive", func() {
+		documents, err := Kubernetes([]byte(`kind: ConfigMap
+metadata:
+  name: app
+data:
+  host: db
+  password: hunter2
+---
+kind: List
+items:
+- kind: ConfigMap
+  metadata:
+    name: other
+  binaryData:
+    token: c2VjcmV0
+`), [][]vars.Path{{{"data", "password"}}, {{"items", "0", "binaryData", "token"}}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents[0].Sensitive).Should(Equal([]vars.Path{{"password"}}))
+		Ω(documents[1].Sensitive).Should(Equal([]vars.Path{{"token"}}))
+	})
+
+	It("rejects data that is not base64 without showing it", func() {
+		_, err := Kubernetes([]byte(`kind: Secret
+metadata:
+  name: app
+data:
+  password: not base64!
+`), nil)
+		Ω(err).Should(MatchError("Secret app: data.password is not base64"))
+	})
+
+	It("rejects streams without Secrets or ConfigMaps", func() {
+		_, err := Kubernetes([]byte("kind: Deployment\n"), nil)
+		Ω(err).Should(MatchError("there are no Secret or ConfigMap manifests"))
+	})
+})
@@ -0,0 +1,119 @@
+package input
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Properties parses a Java .properties file into an ordered map. Keys are
+// separated from values by =, : or whitespace, lines ending in a backslash
+// continue on the next line, and lines starting with # or ! are comments.
+// Dotted keys are kept whole rather than nested.
+func Properties(source []byte) (yaml.MapSlice, error) {
+	mapSlice := yaml.MapSlice{}
+	lines := strings.Split(strings.ReplaceAll(string(source), "\r\n", "\n"), "\n")
+
+	for number := 0; number < len(lines); number++ {
+		start := number + 1
+		line := strings.TrimLeft(lines[number], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		for continues(line) && number+1 < len(lines) {
+			number++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[number], " \t\f")
+		}
+		if continues(line) {
+			line = line[:len(line)-1]
+		}
+
+		key, value, err := property(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", start, err)
+		}
+		mapSlice = append(mapSlice, yaml.MapItem{Key: key, Value: value})
+	}
+
+	return mapSlice, nil
+}
+
+// continues reports whether line ends in an odd number of backslashes.
+func continues(line string) bool {
+	backslashes := len(line) - len(strings.TrimRight(line, "\\"))
+	return backslashes%2 == 1
+}
+
+func property(line string) (string, string, error) {
+	end := 0
+	for end < len(line) {
+		if line[end] == '\\' {
+			end += 2
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[end]) != -1 {
+			break
+		}
+		end++
+	}
+	if end > len(line) {
+		end = len(line)
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(text string) (string, error) {
+	if !strings.Contains(text, "\\") {
+		return text, nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' || i+1 == len(text) {
+			builder.WriteByte(text[i])
+			continue
+		}
+
+		i++
+		switch text[i] {
+		case 't':
+			builder.WriteByte('\t')
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'u':
+			if i+5 > len(text) {
+				return "", fmt.Errorf("truncated unicode escape")
+			}
+			code, err := strconv.ParseUint(text[i+1:i+5], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape \\u%s", text[i+1:i+5])
+			}
+			builder.WriteRune(rune(code))
+			i += 4
+		default:
+			builder.WriteByte(text[i])
+		}
+	}
+	return builder.String(), nil
+}
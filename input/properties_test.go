@@ -0,0 +1,37 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Properties", func() {
+	It("parses each kind of line", func() {
+		mapSlice, err := Properties([]byte(`# comment
+! also a comment
+db.url=jdbc:postgresql://db/app
+db.user : admin
+greeting Hello world
+long = first \
+       second
+escaped\ key = tab\there \u00e9
+empty=
+`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "db.url", Value: "jdbc:postgresql://db/app"},
+			{Key: "db.user", Value: "admin"},
+			{Key: "greeting", Value: "Hello world"},
+			{Key: "long", Value: "first second"},
+			{Key: "escaped key", Value: "tab\there é"},
+			{Key: "empty", Value: ""},
+		}))
+	})
+
+	It("reports invalid escapes", func() {
+		_, err := Properties([]byte("a=1\nb=\\uzzzz\n"))
+		Ω(err).Should(MatchError(`line 2: invalid unicode escape \uzzzz`))
+	})
+})
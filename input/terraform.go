@@ -0,0 +1,48 @@
+package input
+
+import (
+	"fmt"
+
+	"github.com/EngineerBetter/yml2env/vars"
+	"gopkg.in/yaml.v2"
+)
+
+// Terraform parses the output of terraform output -json, in which every
+// output is wrapped as {"sensitive": ..., "type": ..., "value": ...}. The
+// wrapping is removed, and outputs marked sensitive are recorded as such.
+func Terraform(source []byte) (Document, error) {
+	outputs, err := JSON(source)
+	if err != nil {
+		return Document{}, err
+	}
+
+	document := Document{Vars: yaml.MapSlice{}}
+	for _, output := range outputs {
+		name := fmt.Sprint(output.Key)
+		wrapper, ok := output.Value.(yaml.MapSlice)
+		if !ok {
+			return Document{}, fmt.Errorf("output %s is not an object", name)
+		}
+
+		value, found := field(wrapper, "value")
+		if !found {
+			return Document{}, fmt.Errorf("output %s has no value", name)
+		}
+		document.Vars = append(document.Vars, yaml.MapItem{Key: name, Value: value})
+
+		if sensitive, _ := field(wrapper, "sensitive"); sensitive == true {
+			document.Sensitive = append(document.Sensitive, vars.Path{name})
+		}
+	}
+
+	return document, nil
+}
+
+func field(mapSlice yaml.MapSlice, key string) (interface{}, bool) {
+	for _, item := range mapSlice {
+		if item.Key == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,36 @@
+package input_test
+
+import (
+	"encoding/json"
+
+	. "github.com/EngineerBetter/yml2env/input"
+	"github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Terraform", func() {
+	It("unwraps each output and records which are sensitive", func() {
+		document, err := Terraform([]byte(`{
+			"url": {"sensitive": false, "type": "string", "value": "https://x"},
+			"password": {"sensitive": true, "type": "string", "value": "hunter2"},
+			"db": {"sensitive": false, "type": ["object", {"port": "number"}], "value": {"port": 5432}}
+		}`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(document.Vars).Should(Equal(yaml.MapSlice{
+			{Key: "url", Value: "https://x"},
+			{Key: "password", Value: "hunter2"},
+			{Key: "db", Value: yaml.MapSlice{{Key: "port", Value: json.Number("5432")}}},
+		}))
+		Ω(document.Sensitive).Should(Equal([]vars.Path{{"password"}}))
+	})
+
+	It("rejects outputs that are not wrapped", func() {
+		_, err := Terraform([]byte(`{"url": "https://x"}`))
+		Ω(err).Should(MatchError("output url is not an object"))
+
+		_, err = Terraform([]byte(`{"url": {"sensitive": false}}`))
+		Ω(err).Should(MatchError("output url has no value"))
+	})
+})
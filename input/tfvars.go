@@ -0,0 +1,450 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Tfvars parses a Terraform .tfvars file of name = value attributes into an
+// ordered map. Values may be strings, including heredocs, numbers, bools,
+// null, lists and maps; numbers are kept as json.Number so that their exact
+// text survives. Comments start with #, // or /*.
+func Tfvars(source []byte) (yaml.MapSlice, error) {
+	p := tfvarsParser{source: []rune(string(source)), line: 1}
+	mapSlice := yaml.MapSlice{}
+
+	for {
+		if err := p.skip(true); err != nil {
+			return nil, err
+		}
+		if p.done() {
+			return mapSlice, nil
+		}
+
+		name := p.identifier()
+		if name == "" {
+			return nil, p.errorf("expected a variable name, found %q", p.peek())
+		}
+		if hasKey(mapSlice, name) {
+			return nil, p.errorf("%s is set more than once", name)
+		}
+
+		if err := p.skip(false); err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek() != '=' {
+			return nil, p.errorf("expected = after %s", name)
+		}
+		p.next()
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		mapSlice = append(mapSlice, yaml.MapItem{Key: name, Value: value})
+
+		if err := p.skip(false); err != nil {
+			return nil, err
+		}
+		if !p.done() && p.peek() != '\n' {
+			return nil, p.errorf("expected a new line after the value of %s", name)
+		}
+	}
+}
+
+type tfvarsParser struct {
+	source   []rune
+	position int
+	line     int
+}
+
+func (p *tfvarsParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *tfvarsParser) done() bool {
+	return p.position >= len(p.source)
+}
+
+func (p *tfvarsParser) peek() rune {
+	return p.source[p.position]
+}
+
+func (p *tfvarsParser) lookingAt(text string) bool {
+	return strings.HasPrefix(string(p.source[p.position:]), text)
+}
+
+func (p *tfvarsParser) next() rune {
+	r := p.source[p.position]
+	p.position++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+// skip passes over whitespace and comments, and over new lines too if
+// newlines is set. A # or // comment always stops before its new line.
+func (p *tfvarsParser) skip(newlines bool) error {
+	for !p.done() {
+		switch {
+		case p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r':
+			p.next()
+		case p.peek() == '\n' && newlines:
+			p.next()
+		case p.peek() == '#' || p.lookingAt("//"):
+			for !p.done() && p.peek() != '\n' {
+				p.next()
+			}
+		case p.lookingAt("/*"):
+			line := p.line
+			for !p.lookingAt("*/") {
+				if p.done() {
+					return fmt.Errorf("line %d: unterminated comment", line)
+				}
+				p.next()
+			}
+			p.next()
+			p.next()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *tfvarsParser) identifier() string {
+	start := p.position
+	for !p.done() && isIdentifierRune(p.peek(), p.position == start) {
+		p.next()
+	}
+	return string(p.source[start:p.position])
+}
+
+func isIdentifierRune(r rune, first bool) bool {
+	if unicode.IsLetter(r) || r == '_' {
+		return true
+	}
+	return !first && (unicode.IsDigit(r) || r == '-')
+}
+
+func (p *tfvarsParser) value() (interface{}, error) {
+	if err := p.skip(false); err != nil {
+		return nil, err
+	}
+	if p.done() {
+		return nil, p.errorf("expected a value")
+	}
+
+	switch r := p.peek(); {
+	case r == '"':
+		return p.quoted()
+	case p.lookingAt("<<"):
+		return p.heredoc()
+	case r == '[':
+		return p.list()
+	case r == '{':
+		return p.object()
+	case r == '-' || isDigit(r):
+		return p.number()
+	case isIdentifierRune(r, true):
+		switch word := p.identifier(); word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return nil, p.errorf("%s: variables are not allowed in tfvars files", word)
+		}
+	default:
+		return nil, p.errorf("unexpected %q", r)
+	}
+}
+
+func (p *tfvarsParser) quoted() (string, error) {
+	p.next()
+	var value strings.Builder
+
+	for {
+		if p.done() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+
+		switch r := p.next(); {
+		case r == '"':
+			return value.String(), nil
+		case r == '\\':
+			if err := p.escape(&value); err != nil {
+				return "", err
+			}
+		case (r == '$' || r == '%') && p.lookingAt(string(r)+"{"):
+			p.next()
+			p.next()
+			value.WriteRune(r)
+			value.WriteRune('{')
+		case (r == '$' || r == '%') && p.lookingAt("{"):
+			return "", p.errorf("templates are not allowed in tfvars files")
+		default:
+			value.WriteRune(r)
+		}
+	}
+}
+
+func (p *tfvarsParser) escape(value *strings.Builder) error {
+	if p.done() {
+		return p.errorf("unterminated string")
+	}
+
+	switch r := p.next(); r {
+	case 'n':
+		value.WriteRune('\n')
+	case 'r':
+		value.WriteRune('\r')
+	case 't':
+		value.WriteRune('\t')
+	case '"', '\\':
+		value.WriteRune(r)
+	case 'u', 'U':
+		digits := 4
+		if r == 'U' {
+			digits = 8
+		}
+		if p.position+digits > len(p.source) {
+			return p.errorf("invalid unicode escape")
+		}
+		hex := string(p.source[p.position : p.position+digits])
+		code, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return p.errorf("invalid unicode escape \\%c%s", r, hex)
+		}
+		p.position += digits
+		value.WriteRune(rune(code))
+	default:
+		return p.errorf("invalid escape \\%c", r)
+	}
+	return nil
+}
+
+// heredoc reads <<MARKER or <<-MARKER up to a line holding only MARKER. The
+// indented form removes the leading whitespace common to every line.
+func (p *tfvarsParser) heredoc() (string, error) {
+	p.position += 2
+	indented := !p.done() && p.peek() == '-'
+	if indented {
+		p.next()
+	}
+
+	marker := p.identifier()
+	if marker == "" {
+		return "", p.errorf("expected a heredoc marker")
+	}
+	if err := p.skip(false); err != nil {
+		return "", err
+	}
+	if p.done() || p.next() != '\n' {
+		return "", p.errorf("expected a new line after <<%s", marker)
+	}
+
+	start := p.line
+	var lines []string
+	for {
+		if p.done() {
+			return "", fmt.Errorf("line %d: heredoc is missing its closing %s", start, marker)
+		}
+
+		begin := p.position
+		for !p.done() && p.peek() != '\n' {
+			p.next()
+		}
+		line := strings.TrimSuffix(string(p.source[begin:p.position]), "\r")
+
+		if strings.TrimSpace(line) == marker {
+			break
+		}
+		lines = append(lines, line)
+		if !p.done() {
+			p.next()
+		}
+	}
+
+	if indented {
+		lines = dedent(lines)
+	}
+
+	var value strings.Builder
+	for _, line := range lines {
+		value.WriteString(line)
+		value.WriteRune('\n')
+	}
+	return value.String(), nil
+}
+
+func dedent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 || width < indent {
+			indent = width
+		}
+	}
+
+	dedented := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		dedented[i] = line
+	}
+	return dedented
+}
+
+func (p *tfvarsParser) number() (json.Number, error) {
+	start := p.position
+	if p.peek() == '-' {
+		p.next()
+	}
+	p.digits()
+	if !p.done() && p.peek() == '.' {
+		p.next()
+		p.digits()
+	}
+	if !p.done() && (p.peek() == 'e' || p.peek() == 'E') {
+		p.next()
+		if !p.done() && (p.peek() == '+' || p.peek() == '-') {
+			p.next()
+		}
+		p.digits()
+	}
+
+	text := string(p.source[start:p.position])
+	if _, err := strconv.ParseFloat(text, 64); err != nil {
+		return "", p.errorf("invalid number %s", text)
+	}
+	return json.Number(text), nil
+}
+
+func (p *tfvarsParser) digits() {
+	for !p.done() && isDigit(p.peek()) {
+		p.next()
+	}
+}
+
+func (p *tfvarsParser) list() ([]interface{}, error) {
+	p.next()
+	list := []interface{}{}
+
+	for {
+		if err := p.skip(true); err != nil {
+			return nil, err
+		}
+		if p.done() {
+			return nil, p.errorf("unterminated list")
+		}
+		if p.peek() == ']' {
+			p.next()
+			return list, nil
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+
+		if err := p.skip(true); err != nil {
+			return nil, err
+		}
+		if p.done() {
+			return nil, p.errorf("unterminated list")
+		}
+		switch p.peek() {
+		case ',':
+			p.next()
+		case ']':
+		default:
+			return nil, p.errorf("expected , or ] in list, found %q", p.peek())
+		}
+	}
+}
+
+func (p *tfvarsParser) object() (yaml.MapSlice, error) {
+	p.next()
+	object := yaml.MapSlice{}
+
+	for {
+		if err := p.skip(true); err != nil {
+			return nil, err
+		}
+		if p.done() {
+			return nil, p.errorf("unterminated map")
+		}
+		if p.peek() == '}' {
+			p.next()
+			return object, nil
+		}
+
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		if hasKey(object, key) {
+			return nil, p.errorf("key %q is set more than once", key)
+		}
+
+		if err := p.skip(false); err != nil {
+			return nil, err
+		}
+		if p.done() || (p.peek() != '=' && p.peek() != ':') {
+			return nil, p.errorf("expected = after %s", key)
+		}
+		p.next()
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		object = append(object, yaml.MapItem{Key: key, Value: value})
+
+		if err := p.skip(false); err != nil {
+			return nil, err
+		}
+		if !p.done() && p.peek() == ',' {
+			p.next()
+		} else if !p.done() && p.peek() != '\n' && p.peek() != '}' {
+			return nil, p.errorf("expected , or a new line after the value of %s", key)
+		}
+	}
+}
+
+func (p *tfvarsParser) key() (string, error) {
+	if p.peek() == '"' {
+		return p.quoted()
+	}
+	if key := p.identifier(); key != "" {
+		return key, nil
+	}
+	return "", p.errorf("expected a key, found %q", p.peek())
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func hasKey(mapSlice yaml.MapSlice, key string) bool {
+	for _, item := range mapSlice {
+		if item.Key == key {
+			return true
+		}
+	}
+	return false
+}
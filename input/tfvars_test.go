@@ -0,0 +1,94 @@
+package input_test
+
+import (
+	"encoding/json"
+
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Tfvars", func() {
+	It("parses each kind of value", func() {
+		mapSlice, err := Tfvars([]byte(`# comment
+region        = "eu-west-2" // trailing comment
+instances     = 3
+ratio         = -1.5e3
+enabled       = true
+retired       = null
+/* block
+   comment */
+zones = [
+  "a",
+  "b", # more
+]
+tags = {
+  Name  = "web"
+  "cost-centre": 42,
+  nested = { deep = false }
+}
+`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "region", Value: "eu-west-2"},
+			{Key: "instances", Value: json.Number("3")},
+			{Key: "ratio", Value: json.Number("-1.5e3")},
+			{Key: "enabled", Value: true},
+			{Key: "retired", Value: nil},
+			{Key: "zones", Value: []interface{}{"a", "b"}},
+			{Key: "tags", Value: yaml.MapSlice{
+				{Key: "Name", Value: "web"},
+				{Key: "cost-centre", Value: json.Number("42")},
+				{Key: "nested", Value: yaml.MapSlice{{Key: "deep", Value: false}}},
+			}},
+		}))
+	})
+
+	It("unescapes strings", func() {
+		mapSlice, err := Tfvars([]byte(`greeting = "tab\there \"quoted\" é $${literal}"` + "\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "greeting", Value: "tab\there \"quoted\" é ${literal}"},
+		}))
+	})
+
+	It("reads heredocs, removing indentation from the indented form", func() {
+		mapSlice, err := Tfvars([]byte(`plain = <<EOT
+first
+  second
+EOT
+indented = <<-EOT
+    first
+      second
+    EOT
+`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "plain", Value: "first\n  second\n"},
+			{Key: "indented", Value: "first\n  second\n"},
+		}))
+	})
+
+	It("rejects expressions that need Terraform to evaluate", func() {
+		_, err := Tfvars([]byte("a = 1\nb = var.a\n"))
+		Ω(err).Should(MatchError("line 2: var: variables are not allowed in tfvars files"))
+
+		_, err = Tfvars([]byte(`c = "${var.a}"`))
+		Ω(err).Should(MatchError("line 1: templates are not allowed in tfvars files"))
+	})
+
+	It("reports malformed files with their line", func() {
+		_, err := Tfvars([]byte("a = 1\na = 2\n"))
+		Ω(err).Should(MatchError("line 2: a is set more than once"))
+
+		_, err = Tfvars([]byte("a = 1 b = 2\n"))
+		Ω(err).Should(MatchError("line 1: expected a new line after the value of a"))
+
+		_, err = Tfvars([]byte("a = [1, 2\n"))
+		Ω(err).Should(MatchError("line 2: unterminated list"))
+
+		_, err = Tfvars([]byte("a = <<EOT\nnever closed\n"))
+		Ω(err).Should(MatchError("line 2: heredoc is missing its closing EOT"))
+	})
+})
@@ -0,0 +1,88 @@
+package input
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// TOML parses a TOML document into an ordered map, with tables, including
+// dotted ones, as nested maps and arrays of tables as lists of maps. Keys
+// keep the order they were first defined in.
+func TOML(source []byte) (yaml.MapSlice, error) {
+	var document map[string]interface{}
+	metadata, err := toml.Decode(string(source), &document)
+	if err != nil {
+		return nil, err
+	}
+
+	order := map[string]int{}
+	for i, key := range metadata.Keys() {
+		path := key.String()
+		if _, found := order[path]; !found {
+			order[path] = i
+		}
+	}
+
+	return tomlMap(document, "", order), nil
+}
+
+func tomlMap(table map[string]interface{}, prefix string, order map[string]int) yaml.MapSlice {
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return order[tomlPath(prefix, keys[i])] < order[tomlPath(prefix, keys[j])]
+	})
+
+	mapSlice := yaml.MapSlice{}
+	for _, key := range keys {
+		path := tomlPath(prefix, key)
+		mapSlice = append(mapSlice, yaml.MapItem{Key: key, Value: tomlValue(table[key], path, order)})
+	}
+	return mapSlice
+}
+
+func tomlValue(value interface{}, path string, order map[string]int) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return tomlMap(typed, path, order)
+	case []map[string]interface{}:
+		list := make([]interface{}, len(typed))
+		for i, table := range typed {
+			list[i] = tomlMap(table, path, order)
+		}
+		return list
+	case []interface{}:
+		list := make([]interface{}, len(typed))
+		for i, element := range typed {
+			list[i] = tomlValue(element, path, order)
+		}
+		return list
+	case time.Time:
+		// Dates and times without an offset come back in specially named
+		// locations, and are written back without one.
+		switch typed.Location().String() {
+		case "date-local":
+			return typed.Format("2006-01-02")
+		case "time-local":
+			return typed.Format("15:04:05.999999999")
+		case "datetime-local":
+			return typed.Format("2006-01-02T15:04:05.999999999")
+		}
+	}
+	return value
+}
+
+// tomlPath matches the way toml.Key.String quotes keys that are not bare.
+func tomlPath(prefix, key string) string {
+	quoted := toml.Key{key}.String()
+	if prefix == "" {
+		return quoted
+	}
+	return strings.Join([]string{prefix, quoted}, ".")
+}
@@ -0,0 +1,58 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("TOML", func() {
+	It("keeps key order and nests tables", func() {
+		mapSlice, err := TOML([]byte(`
+zeta = 1
+alpha = "x"
+released = 1979-05-27
+
+[database]
+port = 5432
+host = "db"
+
+[database.tls]
+enabled = true
+
+[site]
+owner.name = "me"
+`))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "zeta", Value: int64(1)},
+			{Key: "alpha", Value: "x"},
+			{Key: "released", Value: "1979-05-27"},
+			{Key: "database", Value: yaml.MapSlice{
+				{Key: "port", Value: int64(5432)},
+				{Key: "host", Value: "db"},
+				{Key: "tls", Value: yaml.MapSlice{{Key: "enabled", Value: true}}},
+			}},
+			{Key: "site", Value: yaml.MapSlice{
+				{Key: "owner", Value: yaml.MapSlice{{Key: "name", Value: "me"}}},
+			}},
+		}))
+	})
+
+	It("turns arrays of tables into lists of maps", func() {
+		mapSlice, err := TOML([]byte("[[servers]]\nname = \"a\"\n[[servers]]\nname = \"b\"\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "servers", Value: []interface{}{
+				yaml.MapSlice{{Key: "name", Value: "a"}},
+				yaml.MapSlice{{Key: "name", Value: "b"}},
+			}},
+		}))
+	})
+
+	It("rejects invalid TOML", func() {
+		_, err := TOML([]byte("a = "))
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,222 @@
+package input
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// YAML parses a YAML document into an ordered map, resolving each scalar
+// value to its YAML type. Keys keep the literal text they were written with,
+// so that yes: is named YES rather than TRUE. Only the first document of a
+// stream is read.
+func YAML(source []byte) (yaml.MapSlice, error) {
+	documents, err := decodeDocuments(source, true)
+	if err != nil {
+		return nil, err
+	}
+	return documents[0], nil
+}
+
+// YAMLDocuments parses every document in a YAML stream, resolving each
+// scalar value to its YAML type and keeping the literal text of each key. An
+// empty stream is a single empty document.
+func YAMLDocuments(source []byte) ([]yaml.MapSlice, error) {
+	return decodeDocuments(source, true)
+}
+
+// RawYAMLDocuments parses every document in a YAML stream, keeping the
+// literal text of every scalar key and value as written in the source, so
+// that 0755 stays 0755 rather than becoming 493.
+func RawYAMLDocuments(source []byte) ([]yaml.MapSlice, error) {
+	return decodeDocuments(source, false)
+}
+
+func decodeDocuments(source []byte, resolve bool) ([]yaml.MapSlice, error) {
+	decoder := yamlv3.NewDecoder(bytes.NewReader(source))
+	documents := []yaml.MapSlice{}
+
+	for {
+		var document yamlv3.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		mapSlice, err := decodeDocument(&document, resolve)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, mapSlice)
+	}
+
+	return nonEmpty(documents), nil
+}
+
+func nonEmpty(documents []yaml.MapSlice) []yaml.MapSlice {
+	if len(documents) == 0 {
+		return []yaml.MapSlice{{}}
+	}
+	return documents
+}
+
+func decodeDocument(document *yamlv3.Node, resolve bool) (yaml.MapSlice, error) {
+	if len(document.Content) == 0 {
+		return yaml.MapSlice{}, nil
+	}
+
+	decoder := documentDecoder{expanding: map[*yamlv3.Node]bool{}, resolve: resolve}
+	value, err := decoder.value(document.Content[0])
+	if err != nil {
+		return nil, err
+	}
+
+	mapSlice, ok := value.(yaml.MapSlice)
+	if !ok {
+		return nil, errors.New("document is not a map")
+	}
+	return mapSlice, nil
+}
+
+// maxAliasedNodes bounds how many nodes aliases can expand to in total, so
+// that a small document cannot expand to an enormous one.
+const maxAliasedNodes = 100000
+
+// documentDecoder expands aliases, refusing ones that contain themselves. With
+// resolve set, scalar values are resolved to their YAML types; keys are
+// always left as written.
+type documentDecoder struct {
+	expanding map[*yamlv3.Node]bool
+	aliased   int
+	resolve   bool
+}
+
+func (d *documentDecoder) value(node *yamlv3.Node) (interface{}, error) {
+	if len(d.expanding) > 0 {
+		if d.aliased++; d.aliased > maxAliasedNodes {
+			return nil, errors.New("document contains excessive aliasing")
+		}
+	}
+
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		if d.resolve {
+			return resolveScalar(node)
+		}
+		return node.Value, nil
+	case yamlv3.AliasNode:
+		if d.expanding[node.Alias] {
+			return nil, fmt.Errorf("anchor '%s' value contains itself", node.Value)
+		}
+		d.expanding[node.Alias] = true
+		defer delete(d.expanding, node.Alias)
+		return d.value(node.Alias)
+	case yamlv3.SequenceNode:
+		list := make([]interface{}, len(node.Content))
+		for i, element := range node.Content {
+			value, err := d.value(element)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = value
+		}
+		return list, nil
+	case yamlv3.MappingNode:
+		return d.mapping(node)
+	}
+	return nil, fmt.Errorf("unsupported YAML node at line %d", node.Line)
+}
+
+// mapping follows the YAML merge key convention: keys written in the map
+// take precedence over merged ones, and earlier merged maps take precedence
+// over later ones.
+func (d *documentDecoder) mapping(node *yamlv3.Node) (yaml.MapSlice, error) {
+	mapSlice := yaml.MapSlice{}
+	present := map[string]bool{}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i]; key.Tag != "!!merge" {
+			present[key.Value] = true
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, valueNode := node.Content[i], node.Content[i+1]
+
+		if key.Kind == yamlv3.ScalarNode && key.Tag == "!!merge" {
+			merged, err := d.merge(valueNode)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range merged {
+				if key := item.Key.(string); !present[key] {
+					present[key] = true
+					mapSlice = append(mapSlice, item)
+				}
+			}
+			continue
+		}
+
+		if key.Kind != yamlv3.ScalarNode {
+			return nil, fmt.Errorf("key at line %d is not a scalar", key.Line)
+		}
+
+		value, err := d.value(valueNode)
+		if err != nil {
+			return nil, err
+		}
+		mapSlice = append(mapSlice, yaml.MapItem{Key: key.Value, Value: value})
+	}
+
+	return mapSlice, nil
+}
+
+func (d *documentDecoder) merge(node *yamlv3.Node) (yaml.MapSlice, error) {
+	sources := []*yamlv3.Node{node}
+	if node.Kind == yamlv3.SequenceNode {
+		sources = node.Content
+	}
+
+	merged := yaml.MapSlice{}
+	for _, source := range sources {
+		value, err := d.value(source)
+		if err != nil {
+			return nil, err
+		}
+		mapSlice, ok := value.(yaml.MapSlice)
+		if !ok {
+			return nil, fmt.Errorf("merge at line %d is not a map", source.Line)
+		}
+		merged = append(merged, mapSlice...)
+	}
+	return merged, nil
+}
+
+// resolveScalar resolves a plain scalar the way YAML 1.1 does, as
+// gopkg.in/yaml.v2 always has, so that yes is true and 0755 is 493. Quoted,
+// block and tagged scalars are decoded as they are.
+func resolveScalar(node *yamlv3.Node) (interface{}, error) {
+	var value interface{}
+	if node.Style != 0 {
+		err := node.Decode(&value)
+		return value, err
+	}
+
+	// Only single-line plain scalars can resolve to anything but a string.
+	if strings.Contains(node.Value, "\n") {
+		return node.Value, nil
+	}
+	var wrapped yaml.MapSlice
+	if err := yaml.Unmarshal([]byte("v: "+node.Value), &wrapped); err != nil || len(wrapped) != 1 {
+		return node.Value, nil
+	}
+	return wrapped[0].Value, nil
+}
@@ -0,0 +1,100 @@
+package input_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/input"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("YAML", func() {
+	It("resolves scalar types", func() {
+		mapSlice, err := YAML([]byte("mode: 0755\nenabled: true\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{{Key: "mode", Value: 493}, {Key: "enabled", Value: true}}))
+	})
+
+	It("keeps the literal text of keys", func() {
+		mapSlice, err := YAML([]byte("yes: yes\nn: n\non: 'on'\n0x1F: 0x1F\n1.10: 1.10\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapSlice).Should(Equal(yaml.MapSlice{
+			{Key: "yes", Value: true},
+			{Key: "n", Value: false},
+			{Key: "on", Value: "on"},
+			{Key: "0x1F", Value: 31},
+			{Key: "1.10", Value: 1.1},
+		}))
+	})
+})
+
+var _ = Describe("YAMLDocuments", func() {
+	It("reads every document in a stream", func() {
+		documents, err := YAMLDocuments([]byte("a: 1\n---\na: 2\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents).Should(Equal([]yaml.MapSlice{{{Key: "a", Value: 1}}, {{Key: "a", Value: 2}}}))
+	})
+
+	It("treats an empty stream as one empty document", func() {
+		documents, err := YAMLDocuments([]byte(""))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents).Should(Equal([]yaml.MapSlice{{}}))
+	})
+})
+
+var _ = Describe("RawYAMLDocuments", func() {
+	It("keeps the literal text of scalars", func() {
+		documents, err := RawYAMLDocuments([]byte("mode: 0755\nlimit: 1e3\nversion: 1.10\n1: yes\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents[0]).Should(Equal(yaml.MapSlice{
+			{Key: "mode", Value: "0755"},
+			{Key: "limit", Value: "1e3"},
+			{Key: "version", Value: "1.10"},
+			{Key: "1", Value: "yes"},
+		}))
+	})
+
+	It("keeps nested maps and lists", func() {
+		documents, err := RawYAMLDocuments([]byte("db:\n  port: 05432\norgs: [a, b]\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents[0]).Should(Equal(yaml.MapSlice{
+			{Key: "db", Value: yaml.MapSlice{{Key: "port", Value: "05432"}}},
+			{Key: "orgs", Value: []interface{}{"a", "b"}},
+		}))
+	})
+
+	It("resolves aliases and merge keys", func() {
+		documents, err := RawYAMLDocuments([]byte("base: &base\n  a: 1\n  b: 2\nchild:\n  <<: *base\n  b: 3\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents[0][1]).Should(Equal(yaml.MapItem{
+			Key:   "child",
+			Value: yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: "3"}},
+		}))
+	})
+
+	It("rejects aliases that contain themselves", func() {
+		_, err := RawYAMLDocuments([]byte("a: &x {b: *x}\n"))
+		Ω(err).Should(MatchError("anchor 'x' value contains itself"))
+	})
+
+	It("rejects aliases that expand to an enormous document", func() {
+		bomb := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+		for _, name := range []string{"b", "c", "d", "e", "f", "g"} {
+			previous := string(rune(name[0] - 1))
+			bomb += name + ": &" + name + " [*" + previous + ", *" + previous + ", *" + previous + ", *" + previous + ", *" + previous + ", *" + previous + ", *" + previous + ", *" + previous + ", *" + previous + ", *" + previous + "]\n"
+		}
+
+		_, err := RawYAMLDocuments([]byte(bomb))
+		Ω(err).Should(MatchError("document contains excessive aliasing"))
+	})
+
+	It("reads every document in a stream", func() {
+		documents, err := RawYAMLDocuments([]byte("a: 1\n---\na: 2\n"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(documents).Should(Equal([]yaml.MapSlice{{{Key: "a", Value: "1"}}, {{Key: "a", Value: "2"}}}))
+	})
+
+	It("rejects documents that are not maps", func() {
+		_, err := RawYAMLDocuments([]byte("- a\n"))
+		Ω(err).Should(HaveOccurred())
+	})
+})
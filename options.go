@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/EngineerBetter/yml2env/input"
+	"github.com/EngineerBetter/yml2env/source"
+	"github.com/EngineerBetter/yml2env/vars"
+)
+
+const (
+	conflictLastWins = "last-wins"
+	conflictWarn     = "warn"
+	conflictError    = "error"
+)
+
+type options struct {
+	files    []string
+	conflict string
+	eval     bool
+	verbose  bool
+	raw      bool
+	identity string
+
+	tokenEnv    string
+	httpTimeout time.Duration
+	sources     source.Options
+
+	selectPath string
+	selection  vars.Path
+
+	mappingPath string
+
+	prefix      string
+	stripPrefix string
+	caseName    string
+	caseSet     bool
+	sanitise    bool
+	strict      bool
+	naming      vars.Naming
+
+	formatName string
+	format     input.Format
+	document   string
+	documents  input.DocumentSelector
+
+	composeService string
+
+	flatten   bool
+	bosh      bool
+	separator string
+	maxDepth  int
+
+	listStrategies []string
+	listDelimiter  string
+
+	flattening vars.FlattenOptions
+}
+
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func newFlagSet(opts *options) *flag.FlagSet {
+	flags := flag.NewFlagSet("yml2env", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+
+	flags.Var((*stringsFlag)(&opts.files), "f", "YAML `file` to load; may be repeated, later files override earlier ones")
+	flags.StringVar(&opts.conflict, "on-conflict", conflictLastWins, "`policy` for a later file overriding a key: last-wins, warn or error")
+	flags.BoolVar(&opts.eval, "eval", false, "print exports instead of running a command")
+	flags.BoolVar(&opts.verbose, "verbose", false, "print each variable to standard error as it is set, masking sensitive values")
+	flags.BoolVar(&opts.raw, "raw", false, "export scalars exactly as written in the file rather than as parsed")
+	flags.StringVar(&opts.identity, "identity", os.Getenv(identityEnv), "age identity `file` to decrypt values encrypted by yml2env encrypt with; defaults to $"+identityEnv)
+	flags.StringVar(&opts.tokenEnv, "token-env", "YML2ENV_TOKEN", "environment `variable` holding a bearer token to send when fetching files from http or https URLs")
+	flags.DurationVar(&opts.httpTimeout, "http-timeout", source.DefaultTimeout, "`duration` to wait when fetching a file from a URL before giving up")
+	flags.StringVar(&opts.formatName, "format", string(input.FormatAuto), "`format` of the vars files: yaml, json, dotenv, toml, ini, properties, tfvars, kubernetes for Secret and ConfigMap manifests, credhub for credhub import files, or terraform for the output of terraform output -json; auto picks one from the file name or content")
+	flags.StringVar(&opts.document, "document", "0", "`document` to read from a multi-document file: an index, key=value to find one by its contents, or all to merge them in order")
+	flags.StringVar(&opts.composeService, "compose-service", "", "read the files as docker-compose files, exporting the environment and env_file entries of this `service`")
+	flags.StringVar(&opts.selectPath, "select", "", "only export the map at this `path`, either dotted (environments.staging) or a JSON Pointer (/environments/staging)")
+	flags.StringVar(&opts.mappingPath, "mapping", "", "YAML `file` mapping each variable to export to the path of its value, such as DB_URL: .database.url")
+	flags.StringVar(&opts.prefix, "prefix", "", "`string` added to the start of every variable name, such as TF_VAR_")
+	flags.StringVar(&opts.stripPrefix, "strip-prefix", "", "`string` removed from the start of keys that have it")
+	flags.StringVar(&opts.caseName, "case", string(vars.CaseUpper), "`case` of variable names: upper, preserve, or snake to convert camelCase and kebab-case to SCREAMING_SNAKE; Kubernetes manifests and compose services are preserved unless this is given")
+	flags.BoolVar(&opts.sanitise, "sanitise", false, "replace characters that are not allowed in variable names with underscores")
+	flags.BoolVar(&opts.strict, "strict", false, "reject duplicate keys, and keys that would become the same variable")
+	flags.BoolVar(&opts.flatten, "flatten", false, "flatten nested maps into keys joined by the separator")
+	flags.BoolVar(&opts.bosh, "bosh", false, "export the parts of BOSH vars-store certificates and ssh and rsa keys as variables suffixed _CA, _CERTIFICATE, _PRIVATE_KEY, _PUBLIC_KEY and _PUBLIC_KEY_FINGERPRINT")
+	flags.StringVar(&opts.separator, "separator", "_", "`string` placed between nested keys when flattening")
+	flags.Var((*stringsFlag)(&opts.listStrategies), "list-strategy", "`strategy` for exporting lists: joined, indexed or json; use key=strategy to set it for one key, may be repeated")
+	flags.StringVar(&opts.listDelimiter, "list-delimiter", ",", "`string` placed between the elements of joined lists")
+	flags.IntVar(&opts.maxDepth, "max-depth", 0, "number of `levels` to flatten before exporting the rest as JSON; 0 for no limit")
+
+	return flags
+}
+
+func usageText() string {
+	var defaults bytes.Buffer
+	flags := newFlagSet(&options{})
+	flags.SetOutput(&defaults)
+	flags.PrintDefaults()
+
+	return usage + "\n\nOptions:\n" + defaults.String()
+}
+
+// parseArgs accepts options either side of a single positional YAML file,
+// and treats everything after that as the command to run.
+func parseArgs(args []string) (options, []string, error) {
+	opts := options{}
+	flags := newFlagSet(&opts)
+
+	if err := flags.Parse(args); err != nil {
+		return opts, nil, err
+	}
+	rest := flags.Args()
+
+	if len(opts.files) == 0 {
+		if len(rest) == 0 {
+			return opts, nil, errors.New("no YAML file given")
+		}
+		opts.files = []string{rest[0]}
+
+		if err := flags.Parse(rest[1:]); err != nil {
+			return opts, nil, err
+		}
+		rest = flags.Args()
+	}
+
+	switch opts.conflict {
+	case conflictLastWins, conflictWarn, conflictError:
+	default:
+		return opts, nil, fmt.Errorf("unknown conflict policy %q", opts.conflict)
+	}
+
+	if opts.maxDepth < 0 {
+		return opts, nil, errors.New("--max-depth must not be negative")
+	}
+
+	stdinReads := 0
+	for _, location := range append(opts.files, opts.mappingPath) {
+		if location == source.Stdin {
+			stdinReads++
+		}
+	}
+	if stdinReads > 1 {
+		return opts, nil, errors.New("standard input can only be read once")
+	}
+
+	if opts.httpTimeout <= 0 {
+		return opts, nil, errors.New("--http-timeout must be positive")
+	}
+	opts.sources = source.Options{Token: os.Getenv(opts.tokenEnv), Timeout: opts.httpTimeout}
+
+	var err error
+	if opts.format, err = input.ParseFormat(opts.formatName); err != nil {
+		return opts, nil, err
+	}
+
+	if opts.composeService != "" && opts.format != input.FormatAuto {
+		return opts, nil, errors.New("--compose-service cannot be used with --format")
+	}
+
+	if opts.documents, err = input.ParseDocumentSelector(opts.document); err != nil {
+		return opts, nil, err
+	}
+
+	if opts.selection, err = vars.ParsePath(opts.selectPath); err != nil {
+		return opts, nil, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "case" {
+			opts.caseSet = true
+		}
+	})
+	opts.naming = vars.Naming{Prefix: opts.prefix, StripPrefix: opts.stripPrefix}
+	if opts.naming.Case, err = vars.ParseCase(opts.caseName); err != nil {
+		return opts, nil, err
+	}
+
+	if opts.flattening, err = opts.flattenOptions(); err != nil {
+		return opts, nil, err
+	}
+	// Terraform outputs are often maps and lists, which are only usable
+	// flattened.
+	if opts.format == input.FormatTerraform {
+		opts.flattening.Maps = true
+		if opts.flattening.Lists == "" {
+			opts.flattening.Lists = vars.ListIndexed
+		}
+	}
+
+	if opts.eval && len(rest) > 0 {
+		return opts, nil, errors.New("--eval does not accept a command")
+	}
+	if !opts.eval && len(rest) == 0 {
+		return opts, nil, errors.New("no command given")
+	}
+
+	return opts, rest, nil
+}
+
+func (opts options) flattenOptions() (vars.FlattenOptions, error) {
+	flattenOpts := vars.FlattenOptions{
+		Maps:           opts.flatten,
+		Credentials:    opts.bosh,
+		Separator:      opts.separator,
+		MaxDepth:       opts.maxDepth,
+		ListStrategies: map[string]vars.ListStrategy{},
+		ListDelimiter:  opts.listDelimiter,
+	}
+
+	for _, value := range opts.listStrategies {
+		key, name := "", value
+		if index := strings.LastIndex(value, "="); index != -1 {
+			key, name = value[:index], value[index+1:]
+		}
+
+		strategy, err := vars.ParseListStrategy(name)
+		if err != nil {
+			return flattenOpts, err
+		}
+
+		if key == "" {
+			flattenOpts.Lists = strategy
+		} else {
+			flattenOpts.ListStrategies[key] = strategy
+		}
+	}
+
+	return flattenOpts, nil
+}
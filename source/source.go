@@ -0,0 +1,141 @@
+package source
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Stdin is the location that reads from standard input.
+const Stdin = "-"
+
+// DefaultTimeout bounds fetching a URL when Options give no timeout.
+const DefaultTimeout = 30 * time.Second
+
+// ErrNotExist is returned when a location does not exist.
+var ErrNotExist = errors.New("does not exist")
+
+// Options configure fetching locations that are URLs.
+type Options struct {
+	// Token is sent as a bearer token, if it is set.
+	Token   string
+	Timeout time.Duration
+}
+
+// Read returns the contents of location, which is either a path, Stdin, an
+// http or https URL, a Vault secret or a path of SSM parameters. Paths such
+// as /dev/fd/3 that refer to pipes are read to the end.
+func Read(location string, opts Options) ([]byte, error) {
+	if location == Stdin {
+		return io.ReadAll(os.Stdin)
+	}
+	if IsURL(location) {
+		return fetch(location, opts)
+	}
+	if IsVault(location) {
+		return vault(location, opts)
+	}
+	if IsSSM(location) {
+		return ssm(location, opts)
+	}
+
+	bytes, err := os.ReadFile(location)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	return bytes, err
+}
+
+// IsURL reports whether location is an http or https URL.
+func IsURL(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// Name is the part of location that names what it holds, without the query
+// or fragment of a URL, so that its extension can be used to pick a format.
+func Name(location string) string {
+	if !IsURL(location) {
+		return location
+	}
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	return parsed.Path
+}
+
+// fetch gets a URL. A fragment of sha256=<hex> pins the digest of the
+// response, which is rejected if it differs. Fragments are never sent.
+func fetch(location string, opts Options) ([]byte, error) {
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+
+	pin := ""
+	if parsed.Fragment != "" {
+		if !strings.HasPrefix(parsed.Fragment, "sha256=") {
+			return nil, fmt.Errorf("unknown fragment #%s; only #sha256=<digest> is supported", parsed.Fragment)
+		}
+		pin = strings.ToLower(strings.TrimPrefix(parsed.Fragment, "sha256="))
+		parsed.Fragment = ""
+	}
+
+	request, err := http.NewRequest(http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Token != "" {
+		request.Header.Set("Authorization", "Bearer "+opts.Token)
+	}
+
+	response, body, err := get(request, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrNotExist
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("the server responded %s", response.Status)
+	}
+
+	if pin != "" {
+		digest := sha256.Sum256(body)
+		if actual := hex.EncodeToString(digest[:]); actual != pin {
+			return nil, fmt.Errorf("its sha256 is %s, not the pinned %s", actual, pin)
+		}
+	}
+	return body, nil
+}
+
+// get makes request, giving up after timeout, and reads the whole response.
+func get(request *http.Request, timeout time.Duration) (*http.Response, []byte, error) {
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	client := http.Client{Timeout: timeout}
+
+	response, err := client.Do(request)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, nil, err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return response, body, nil
+}
@@ -0,0 +1,106 @@
+package source_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/EngineerBetter/yml2env/source"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Read", func() {
+	It("reads files", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "vars.yml")
+		Ω(os.WriteFile(path, []byte("a: 1\n"), 0600)).Should(Succeed())
+
+		bytes, err := Read(path, Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(bytes)).Should(Equal("a: 1\n"))
+	})
+
+	It("reports files that do not exist", func() {
+		_, err := Read("no/such/file.yml", Options{})
+		Ω(err).Should(Equal(ErrNotExist))
+	})
+
+	Describe("URLs", func() {
+		var server *httptest.Server
+		var authorization string
+		body := "a: 1\n"
+		digest := sha256.Sum256([]byte(body))
+		pin := hex.EncodeToString(digest[:])
+
+		BeforeEach(func() {
+			authorization = ""
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				switch r.URL.Path {
+				case "/vars.yml":
+					w.Write([]byte(body))
+				case "/slow.yml":
+					time.Sleep(200 * time.Millisecond)
+				case "/forbidden.yml":
+					http.Error(w, "no", http.StatusForbidden)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			DeferCleanup(server.Close)
+		})
+
+		It("fetches them", func() {
+			bytes, err := Read(server.URL+"/vars.yml", Options{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(bytes)).Should(Equal(body))
+			Ω(authorization).Should(BeEmpty())
+		})
+
+		It("sends the bearer token", func() {
+			_, err := Read(server.URL+"/vars.yml", Options{Token: "t0ken"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(authorization).Should(Equal("Bearer t0ken"))
+		})
+
+		It("checks the pinned sha256", func() {
+			bytes, err := Read(server.URL+"/vars.yml#sha256="+pin, Options{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(bytes)).Should(Equal(body))
+
+			_, err = Read(server.URL+"/vars.yml#sha256=abc123", Options{})
+			Ω(err).Should(MatchError("its sha256 is " + pin + ", not the pinned abc123"))
+		})
+
+		It("rejects other fragments", func() {
+			_, err := Read(server.URL+"/vars.yml#md5=abc123", Options{})
+			Ω(err).Should(MatchError("unknown fragment #md5=abc123; only #sha256=<digest> is supported"))
+		})
+
+		It("reports URLs that do not exist", func() {
+			_, err := Read(server.URL+"/missing.yml", Options{})
+			Ω(err).Should(Equal(ErrNotExist))
+		})
+
+		It("reports other failed responses", func() {
+			_, err := Read(server.URL+"/forbidden.yml", Options{})
+			Ω(err).Should(MatchError("the server responded 403 Forbidden"))
+		})
+
+		It("gives up after the timeout", func() {
+			_, err := Read(server.URL+"/slow.yml", Options{Timeout: 50 * time.Millisecond})
+			Ω(err).Should(MatchError(ContainSubstring("Client.Timeout exceeded")))
+		})
+	})
+})
+
+var _ = Describe("Name", func() {
+	It("removes the query and fragment from URLs", func() {
+		Ω(Name("https://config.internal/team/vars.yml?ref=main#sha256=abc")).Should(Equal("/team/vars.yml"))
+		Ω(Name("ci/vars.yml")).Should(Equal("ci/vars.yml"))
+	})
+})
@@ -0,0 +1,220 @@
+package source
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ssmScheme = "ssm://"
+
+// IsSSM reports whether location is a path of AWS SSM parameters, such as
+// ssm:///concourse/main/app.
+func IsSSM(location string) bool {
+	return strings.HasPrefix(location, ssmScheme)
+}
+
+type awsCredentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+type ssmParameter struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+// ssm reads every parameter beneath a path in AWS SSM Parameter Store,
+// decrypting SecureStrings, as a JSON object keyed by the segments of their
+// names below the path joined with underscores. Credentials and the region
+// are taken from the standard AWS environment variables, and
+// AWS_ENDPOINT_URL_SSM or AWS_ENDPOINT_URL override the endpoint, for
+// LocalStack and the like.
+func ssm(location string, opts Options) ([]byte, error) {
+	path := "/" + strings.Trim(strings.TrimPrefix(location, ssmScheme), "/")
+
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		return nil, errors.New("AWS_REGION is not set")
+	}
+
+	credentials := awsCredentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if credentials.accessKeyID == "" || credentials.secretAccessKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_SSM")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if endpoint == "" {
+		endpoint = "https://ssm." + region + ".amazonaws.com"
+	}
+
+	var parameters []ssmParameter
+	nextToken := ""
+	for {
+		page, err := ssmParametersByPath(endpoint, region, credentials, path, nextToken, opts)
+		if err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, page.Parameters...)
+		if nextToken = page.NextToken; nextToken == "" {
+			break
+		}
+	}
+	if len(parameters) == 0 {
+		return nil, ErrNotExist
+	}
+
+	sort.Slice(parameters, func(i, j int) bool { return parameters[i].Name < parameters[j].Name })
+	values := map[string]string{}
+	names := map[string]string{}
+	for _, parameter := range parameters {
+		key := strings.ReplaceAll(strings.Trim(strings.TrimPrefix(parameter.Name, path), "/"), "/", "_")
+		if previous, found := names[key]; found {
+			return nil, fmt.Errorf("parameters %s and %s both become %s", previous, parameter.Name, key)
+		}
+		names[key] = parameter.Name
+		values[key] = parameter.Value
+	}
+	return json.Marshal(values)
+}
+
+type ssmPage struct {
+	Parameters []ssmParameter `json:"Parameters"`
+	NextToken  string         `json:"NextToken"`
+}
+
+func ssmParametersByPath(endpoint, region string, credentials awsCredentials, path, nextToken string, opts Options) (ssmPage, error) {
+	var page ssmPage
+
+	input := map[string]interface{}{"Path": path, "Recursive": true, "WithDecryption": true}
+	if nextToken != "" {
+		input["NextToken"] = nextToken
+	}
+	body, err := json.Marshal(input)
+	if err != nil {
+		return page, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return page, err
+	}
+	request.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	request.Header.Set("X-Amz-Target", "AmazonSSM.GetParametersByPath")
+	signV4(request, body, credentials, region, "ssm", time.Now())
+
+	response, responseBody, err := get(request, opts.Timeout)
+	if err != nil {
+		return page, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		var awsErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(responseBody, &awsErr) == nil && awsErr.Type != "" {
+			// Types can be given as a URI, such as
+			// com.amazonaws.ssm#ParameterNotFound.
+			awsErr.Type = awsErr.Type[strings.LastIndex(awsErr.Type, "#")+1:]
+			if awsErr.Message == "" {
+				return page, fmt.Errorf("SSM responded %s: %s", response.Status, awsErr.Type)
+			}
+			return page, fmt.Errorf("SSM responded %s: %s: %s", response.Status, awsErr.Type, awsErr.Message)
+		}
+		return page, fmt.Errorf("SSM responded %s", response.Status)
+	}
+
+	if err := json.Unmarshal(responseBody, &page); err != nil {
+		return page, fmt.Errorf("SSM responded with invalid JSON: %s", err)
+	}
+	return page, nil
+}
+
+// signV4 adds an AWS Signature Version 4 to request, whose body is body.
+func signV4(request *http.Request, body []byte, credentials awsCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	if credentials.sessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.sessionToken)
+	}
+
+	headers := map[string]string{"host": request.URL.Host}
+	if request.ContentLength > 0 {
+		headers["content-length"] = strconv.FormatInt(request.ContentLength, 10)
+	}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	uri := request.URL.EscapedPath()
+	if uri == "" {
+		uri = "/"
+	}
+	// Requests to the JSON APIs never have a query.
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		uri,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := []byte("AWS4" + credentials.secretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+credentials.accessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
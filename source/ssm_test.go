@@ -0,0 +1,146 @@
+package source_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/EngineerBetter/yml2env/source"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SSM", func() {
+	var server *httptest.Server
+	var requests []map[string]interface{}
+	var authorizations, tokens []string
+	var parameters []string
+
+	BeforeEach(func() {
+		requests, authorizations, tokens = nil, nil, nil
+		parameters = []string{
+			`{"Name":"/concourse/main/app/db/password","Type":"SecureString","Value":"hunter2"}`,
+			`{"Name":"/concourse/main/app/db/port","Type":"String","Value":"5432"}`,
+			`{"Name":"/concourse/main/app/region","Type":"String","Value":"eu-west-2"}`,
+		}
+
+		// The fake returns a page per parameter, to check that every page is
+		// read.
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var input map[string]interface{}
+			Ω(json.NewDecoder(r.Body).Decode(&input)).Should(Succeed())
+			requests = append(requests, input)
+			authorizations = append(authorizations, r.Header.Get("Authorization"))
+			tokens = append(tokens, r.Header.Get("X-Amz-Security-Token"))
+
+			if r.Header.Get("X-Amz-Target") != "AmazonSSM.GetParametersByPath" || r.Header.Get("Content-Type") != "application/x-amz-json-1.1" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"com.amazonaws.ssm#InvalidAction"}`))
+				return
+			}
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type":"UnrecognizedClientException","message":"The security token included in the request is invalid."}`))
+				return
+			}
+			if !strings.HasPrefix(input["Path"].(string), "/concourse") {
+				w.Write([]byte(`{"Parameters":[]}`))
+				return
+			}
+
+			page := 0
+			if token, ok := input["NextToken"].(string); ok {
+				page = int(token[0] - '0')
+			}
+			next := ""
+			if page+1 < len(parameters) {
+				next = string(rune('0' + page + 1))
+			}
+			w.Write([]byte(`{"Parameters":[` + parameters[page] + `],"NextToken":"` + next + `"}`))
+		}))
+		DeferCleanup(server.Close)
+
+		GinkgoT().Setenv("AWS_ENDPOINT_URL_SSM", server.URL)
+		GinkgoT().Setenv("AWS_ENDPOINT_URL", "")
+		GinkgoT().Setenv("AWS_REGION", "eu-west-2")
+		GinkgoT().Setenv("AWS_DEFAULT_REGION", "")
+		GinkgoT().Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+		GinkgoT().Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+		GinkgoT().Setenv("AWS_SESSION_TOKEN", "")
+	})
+
+	It("recognises SSM locations as secret stores", func() {
+		Ω(IsSSM("ssm:///concourse/main")).Should(BeTrue())
+		Ω(IsSecretStore("ssm:///concourse/main")).Should(BeTrue())
+	})
+
+	It("reads every parameter beneath the path, decrypted, keyed by the segments of its name", func() {
+		bytes, err := Read("ssm:///concourse/main/app", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(bytes)).Should(Equal(`{"db_password":"hunter2","db_port":"5432","region":"eu-west-2"}`))
+
+		Ω(requests).Should(HaveLen(3))
+		Ω(requests[0]).Should(Equal(map[string]interface{}{"Path": "/concourse/main/app", "Recursive": true, "WithDecryption": true}))
+		Ω(requests[2]).Should(HaveKeyWithValue("NextToken", "2"))
+		Ω(authorizations[0]).Should(MatchRegexp(`^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/\d{8}/eu-west-2/ssm/aws4_request, SignedHeaders=content-length;content-type;host;x-amz-date;x-amz-target, Signature=[0-9a-f]{64}$`))
+	})
+
+	It("sends the session token", func() {
+		GinkgoT().Setenv("AWS_SESSION_TOKEN", "session")
+
+		_, err := Read("ssm://concourse/main/app/", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(tokens[0]).Should(Equal("session"))
+		Ω(authorizations[0]).Should(ContainSubstring("SignedHeaders=content-length;content-type;host;x-amz-date;x-amz-security-token;x-amz-target,"))
+	})
+
+	It("uses AWS_ENDPOINT_URL and AWS_DEFAULT_REGION", func() {
+		GinkgoT().Setenv("AWS_ENDPOINT_URL_SSM", "")
+		GinkgoT().Setenv("AWS_ENDPOINT_URL", server.URL)
+		GinkgoT().Setenv("AWS_REGION", "")
+		GinkgoT().Setenv("AWS_DEFAULT_REGION", "us-east-1")
+
+		_, err := Read("ssm:///concourse/main/app", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(authorizations[0]).Should(ContainSubstring("/us-east-1/ssm/aws4_request"))
+	})
+
+	It("reports paths without any parameters", func() {
+		_, err := Read("ssm:///other", Options{})
+		Ω(err).Should(Equal(ErrNotExist))
+	})
+
+	It("reads parameters that are also paths of others", func() {
+		parameters = append(parameters, `{"Name":"/concourse/main/app/db","Type":"String","Value":"postgres"}`)
+
+		bytes, err := Read("ssm:///concourse/main/app", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(bytes)).Should(Equal(`{"db":"postgres","db_password":"hunter2","db_port":"5432","region":"eu-west-2"}`))
+	})
+
+	It("rejects parameters whose names become the same key", func() {
+		parameters = append(parameters, `{"Name":"/concourse/main/app/db_port","Type":"String","Value":"5433"}`)
+
+		_, err := Read("ssm:///concourse/main/app", Options{})
+		Ω(err).Should(MatchError("parameters /concourse/main/app/db/port and /concourse/main/app/db_port both become db_port"))
+	})
+
+	It("reports the errors SSM gives", func() {
+		GinkgoT().Setenv("AWS_ACCESS_KEY_ID", "WRONG")
+
+		_, err := Read("ssm:///concourse/main/app", Options{})
+		Ω(err).Should(MatchError("SSM responded 400 Bad Request: UnrecognizedClientException: The security token included in the request is invalid."))
+	})
+
+	It("requires a region and credentials", func() {
+		GinkgoT().Setenv("AWS_REGION", "")
+		_, err := Read("ssm:///concourse/main/app", Options{})
+		Ω(err).Should(MatchError("AWS_REGION is not set"))
+
+		GinkgoT().Setenv("AWS_REGION", "eu-west-2")
+		GinkgoT().Setenv("AWS_SECRET_ACCESS_KEY", "")
+		_, err = Read("ssm:///concourse/main/app", Options{})
+		Ω(err).Should(MatchError("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set"))
+	})
+})
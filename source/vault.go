@@ -0,0 +1,142 @@
+package source
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const vaultScheme = "vault://"
+
+// IsVault reports whether location is a Vault secret, such as
+// vault://secret/data/team/app.
+func IsVault(location string) bool {
+	return strings.HasPrefix(location, vaultScheme)
+}
+
+// IsSecretStore reports whether every value read from location is secret.
+func IsSecretStore(location string) bool {
+	return IsVault(location) || IsSSM(location)
+}
+
+type vaultResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []string        `json:"errors"`
+}
+
+// vault reads a KV secret over the Vault HTTP API from VAULT_ADDR, with the
+// token in VAULT_TOKEN or ~/.vault-token, as the JSON object of its keys and
+// values. The path can be given as the API path of a version 2 secret, such
+// as secret/data/team/app, or as the vault kv command takes it, such as
+// secret/team/app, in which case the mount is looked up to find its version.
+func vault(location string, opts Options) ([]byte, error) {
+	address := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if address == "" {
+		return nil, errors.New("VAULT_ADDR is not set")
+	}
+	token, err := vaultToken()
+	if err != nil {
+		return nil, err
+	}
+
+	v := vaultClient{address: address, token: token, opts: opts}
+	path := strings.Trim(strings.TrimPrefix(location, vaultScheme), "/")
+	if path == "" {
+		return nil, errors.New("no secret path given")
+	}
+
+	var mount vaultMount
+	// Tokens are often not allowed to look up mounts, in which case the path
+	// is read as it was given.
+	if err := v.read("sys/internal/ui/mounts/"+path, &mount); err == nil && mount.Options.Version == "2" {
+		if relative := strings.TrimPrefix(path, mount.Path); !strings.HasPrefix(relative, "data/") {
+			path = mount.Path + "data/" + relative
+		}
+	}
+
+	var data json.RawMessage
+	if err := v.read(path, &data); err != nil {
+		return nil, err
+	}
+
+	var v2 struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	if json.Unmarshal(data, &v2) == nil && len(v2.Data) > 0 && len(v2.Metadata) > 0 {
+		data = v2.Data
+	}
+	if string(data) == "null" {
+		return nil, ErrNotExist
+	}
+	return data, nil
+}
+
+type vaultMount struct {
+	Path    string `json:"path"`
+	Options struct {
+		Version string `json:"version"`
+	} `json:"options"`
+}
+
+type vaultClient struct {
+	address string
+	token   string
+	opts    Options
+}
+
+// read gets the data of the response to a request for path.
+func (v vaultClient) read(path string, data interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, v.address+"/v1/"+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("X-Vault-Token", v.token)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		request.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	response, body, err := get(request, v.opts.Timeout)
+	if err != nil {
+		return err
+	}
+
+	var decoded vaultResponse
+	decodeErr := json.Unmarshal(body, &decoded)
+	if response.StatusCode == http.StatusNotFound {
+		return ErrNotExist
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		if decodeErr == nil && len(decoded.Errors) > 0 {
+			return fmt.Errorf("vault responded %s: %s", response.Status, strings.Join(decoded.Errors, "; "))
+		}
+		return fmt.Errorf("vault responded %s", response.Status)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("vault responded with invalid JSON: %s", decodeErr)
+	}
+	if len(decoded.Data) == 0 {
+		return ErrNotExist
+	}
+
+	return json.Unmarshal(decoded.Data, data)
+}
+
+func vaultToken() (string, error) {
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err == nil {
+		token, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+		if err == nil && strings.TrimSpace(string(token)) != "" {
+			return strings.TrimSpace(string(token)), nil
+		}
+	}
+	return "", errors.New("VAULT_TOKEN is not set, and there is no ~/.vault-token")
+}
@@ -0,0 +1,135 @@
+package source_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/EngineerBetter/yml2env/source"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeVault serves a KV version 2 mount at secret/ and a version 1 mount at
+// kv/, refusing mount lookups unless lookups is set.
+func fakeVault(token string, lookups bool) *httptest.Server {
+	mounts := map[string]string{"secret/": `{"data":{"path":"secret/","type":"kv","options":{"version":"2"}}}`, "kv/": `{"data":{"path":"kv/","type":"kv","options":{"version":"1"}}}`}
+	secrets := map[string]string{
+		"/v1/secret/data/team/app": `{"data":{"data":{"password":"hunter2","port":"5432"},"metadata":{"version":3}}}`,
+		"/v1/kv/team/app":          `{"data":{"password":"hunter1"}}`,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		if mountPath := strings.TrimPrefix(r.URL.Path, "/v1/sys/internal/ui/mounts/"); mountPath != r.URL.Path {
+			for mount, response := range mounts {
+				if lookups && strings.HasPrefix(mountPath, mount) {
+					w.Write([]byte(response))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		if response, ok := secrets[r.URL.Path]; ok {
+			w.Write([]byte(response))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[]}`))
+	}))
+}
+
+var _ = Describe("Vault", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = fakeVault("t0ken", true)
+		DeferCleanup(server.Close)
+		GinkgoT().Setenv("VAULT_ADDR", server.URL)
+		GinkgoT().Setenv("VAULT_TOKEN", "t0ken")
+	})
+
+	It("recognises Vault locations as secret stores", func() {
+		Ω(IsVault("vault://secret/data/team/app")).Should(BeTrue())
+		Ω(IsSecretStore("vault://secret/data/team/app")).Should(BeTrue())
+		Ω(IsSecretStore("https://config.internal/vars.yml")).Should(BeFalse())
+	})
+
+	It("reads version 2 secrets by their API path", func() {
+		bytes, err := Read("vault://secret/data/team/app", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(bytes)).Should(Equal(`{"password":"hunter2","port":"5432"}`))
+	})
+
+	It("reads version 2 secrets by the path the vault kv command takes", func() {
+		bytes, err := Read("vault://secret/team/app", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(bytes)).Should(Equal(`{"password":"hunter2","port":"5432"}`))
+	})
+
+	It("reads version 1 secrets", func() {
+		bytes, err := Read("vault://kv/team/app", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(bytes)).Should(Equal(`{"password":"hunter1"}`))
+	})
+
+	It("reads the API path as given when the token cannot look up mounts", func() {
+		server = fakeVault("t0ken", false)
+		DeferCleanup(server.Close)
+		GinkgoT().Setenv("VAULT_ADDR", server.URL)
+
+		bytes, err := Read("vault://secret/data/team/app", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(string(bytes)).Should(Equal(`{"password":"hunter2","port":"5432"}`))
+
+		_, err = Read("vault://secret/team/app", Options{})
+		Ω(err).Should(Equal(ErrNotExist))
+	})
+
+	It("reads the token from ~/.vault-token", func() {
+		home := GinkgoT().TempDir()
+		Ω(os.WriteFile(filepath.Join(home, ".vault-token"), []byte("t0ken\n"), 0600)).Should(Succeed())
+		GinkgoT().Setenv("HOME", home)
+		GinkgoT().Setenv("VAULT_TOKEN", "")
+
+		_, err := Read("vault://secret/data/team/app", Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("reports secrets that do not exist", func() {
+		_, err := Read("vault://secret/data/team/missing", Options{})
+		Ω(err).Should(Equal(ErrNotExist))
+	})
+
+	It("reports the errors Vault gives", func() {
+		GinkgoT().Setenv("VAULT_TOKEN", "wrong")
+
+		_, err := Read("vault://secret/data/team/app", Options{})
+		Ω(err).Should(MatchError("vault responded 403 Forbidden: permission denied"))
+	})
+
+	It("requires VAULT_ADDR", func() {
+		GinkgoT().Setenv("VAULT_ADDR", "")
+
+		_, err := Read("vault://secret/data/team/app", Options{})
+		Ω(err).Should(MatchError("VAULT_ADDR is not set"))
+	})
+
+	It("requires a token", func() {
+		GinkgoT().Setenv("HOME", GinkgoT().TempDir())
+		GinkgoT().Setenv("VAULT_TOKEN", "")
+
+		_, err := Read("vault://secret/data/team/app", Options{})
+		Ω(err).Should(MatchError("VAULT_TOKEN is not set, and there is no ~/.vault-token"))
+	})
+})
@@ -0,0 +1,31 @@
+package vars
+
+import "gopkg.in/yaml.v2"
+
+var credentialFields = map[string]bool{
+	"ca":                     true,
+	"certificate":            true,
+	"private_key":            true,
+	"public_key":             true,
+	"public_key_fingerprint": true,
+}
+
+// IsCredential reports whether mapSlice has the shape of a certificate, or
+// of an ssh or rsa key, as generated into a BOSH vars store: a private_key
+// alongside a certificate or public_key, and nothing but scalars from
+// credentialFields.
+func IsCredential(mapSlice yaml.MapSlice) bool {
+	found := map[string]bool{}
+	for _, item := range mapSlice {
+		key, ok := item.Key.(string)
+		if !ok || !credentialFields[key] {
+			return false
+		}
+		if _, ok := Scalar(item.Value); !ok {
+			return false
+		}
+		found[key] = true
+	}
+
+	return found["private_key"] && (found["certificate"] || found["public_key"])
+}
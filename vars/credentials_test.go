@@ -0,0 +1,22 @@
+package vars_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("IsCredential", func() {
+	DescribeTable("recognising vars-store shapes",
+		func(mapSlice yaml.MapSlice, expected bool) {
+			Ω(IsCredential(mapSlice)).Should(Equal(expected))
+		},
+		Entry("certificate", yaml.MapSlice{{Key: "ca", Value: "A"}, {Key: "certificate", Value: "C"}, {Key: "private_key", Value: "K"}}, true),
+		Entry("rsa key", yaml.MapSlice{{Key: "private_key", Value: "K"}, {Key: "public_key", Value: "P"}}, true),
+		Entry("ssh key", yaml.MapSlice{{Key: "private_key", Value: "K"}, {Key: "public_key", Value: "P"}, {Key: "public_key_fingerprint", Value: "F"}}, true),
+		Entry("no private key", yaml.MapSlice{{Key: "certificate", Value: "C"}}, false),
+		Entry("other fields", yaml.MapSlice{{Key: "private_key", Value: "K"}, {Key: "public_key", Value: "P"}, {Key: "host", Value: "x"}}, false),
+		Entry("nested values", yaml.MapSlice{{Key: "private_key", Value: yaml.MapSlice{}}, {Key: "public_key", Value: "P"}}, false),
+	)
+})
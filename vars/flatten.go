@@ -0,0 +1,174 @@
+package vars
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ListStrategy says how a sequence is exported.
+type ListStrategy string
+
+const (
+	// ListJoined exports a sequence of scalars as one value joined by a delimiter.
+	ListJoined ListStrategy = "joined"
+	// ListIndexed exports each element under its index, plus a count.
+	ListIndexed ListStrategy = "indexed"
+	// ListJSON exports a sequence as one JSON-encoded value.
+	ListJSON ListStrategy = "json"
+)
+
+// ParseListStrategy validates the name of a ListStrategy.
+func ParseListStrategy(name string) (ListStrategy, error) {
+	switch strategy := ListStrategy(name); strategy {
+	case ListJoined, ListIndexed, ListJSON:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown list strategy %q", name)
+}
+
+// FlattenOptions controls how nested maps and sequences are turned into
+// top-level keys. Values that no option applies to are left in place.
+type FlattenOptions struct {
+	// Maps enables flattening of nested maps.
+	Maps bool
+	// Credentials flattens maps for which IsCredential is true even when
+	// Maps is not set, and regardless of MaxDepth.
+	Credentials bool
+	// Separator is placed between the keys of each level.
+	Separator string
+	// MaxDepth is the number of levels to flatten; maps nested any deeper are
+	// exported as JSON. Zero means no limit.
+	MaxDepth int
+
+	// Lists is the strategy for sequences not named in ListStrategies. The
+	// empty string leaves them in place.
+	Lists ListStrategy
+	// ListStrategies overrides Lists for the sequence at each source path.
+	ListStrategies map[string]ListStrategy
+	// ListDelimiter is placed between the elements of joined sequences.
+	ListDelimiter string
+}
+
+// Flatten replaces nested maps and sequences with top-level keys made by
+// joining the keys on the way down, so that {database: {host: x}} becomes
+// {database_host: x}. It is an error for two different paths to produce the
+// same key.
+func Flatten(mapSlice yaml.MapSlice, opts FlattenOptions) (yaml.MapSlice, error) {
+	f := flattener{opts: opts, sources: map[string]string{}}
+	if err := f.flattenMap(mapSlice, "", "", 0); err != nil {
+		return nil, err
+	}
+	return f.result, nil
+}
+
+type flattener struct {
+	opts    FlattenOptions
+	result  yaml.MapSlice
+	sources map[string]string
+}
+
+func (f *flattener) flattenMap(mapSlice yaml.MapSlice, namePrefix, pathPrefix string, depth int) error {
+	for _, item := range mapSlice {
+		key, ok := Scalar(item.Key)
+		if !ok {
+			return fmt.Errorf("%s contains a key that is not a scalar", describe(pathPrefix))
+		}
+		name := key
+		if namePrefix != "" {
+			name = namePrefix + f.opts.Separator + key
+		}
+
+		if err := f.flatten(name, join(pathPrefix, key), item.Value, depth); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *flattener) flatten(name, path string, value interface{}, depth int) error {
+	switch typed := value.(type) {
+	case yaml.MapSlice:
+		if f.opts.Credentials && IsCredential(typed) {
+			return f.flattenMap(typed, name, path, depth+1)
+		}
+		if !f.opts.Maps {
+			return f.add(name, path, value)
+		}
+		if f.opts.MaxDepth == 0 || depth < f.opts.MaxDepth {
+			return f.flattenMap(typed, name, path, depth+1)
+		}
+		return f.addJSON(name, path, typed)
+	case []interface{}:
+		return f.flattenList(name, path, typed, depth)
+	}
+
+	return f.add(name, path, value)
+}
+
+func (f *flattener) flattenList(name, path string, list []interface{}, depth int) error {
+	strategy, found := f.opts.ListStrategies[path]
+	if !found {
+		strategy = f.opts.Lists
+	}
+
+	switch strategy {
+	case ListJoined:
+		elements := make([]string, len(list))
+		for i, element := range list {
+			value, ok := Scalar(element)
+			if !ok {
+				return fmt.Errorf("cannot join %s as it contains maps or lists; try the indexed or json list strategy", path)
+			}
+			elements[i] = value
+		}
+		return f.add(name, path, keepSecret(list, strings.Join(elements, f.opts.ListDelimiter)))
+	case ListIndexed:
+		for i, element := range list {
+			index := strconv.Itoa(i)
+			if err := f.flatten(name+f.opts.Separator+index, path+"["+index+"]", element, depth+1); err != nil {
+				return err
+			}
+		}
+		return f.add(name+f.opts.Separator+"count", path, strconv.Itoa(len(list)))
+	case ListJSON:
+		return f.addJSON(name, path, list)
+	}
+
+	return f.add(name, path, list)
+}
+
+func (f *flattener) addJSON(name, path string, value interface{}) error {
+	encoded, err := JSON(value)
+	if err != nil {
+		return fmt.Errorf("could not encode %s: %s", path, err)
+	}
+	return f.add(name, path, keepSecret(value, encoded))
+}
+
+// keepSecret marks value as Secret if it was made from a secret.
+func keepSecret(from interface{}, value string) interface{} {
+	if IsSecret(from) {
+		return Secret{Value: value}
+	}
+	return value
+}
+
+func (f *flattener) add(name, path string, value interface{}) error {
+	if existing, found := f.sources[name]; found {
+		return fmt.Errorf("%s and %s both flatten to %s", existing, path, name)
+	}
+	f.sources[name] = path
+	f.result = append(f.result, yaml.MapItem{Key: name, Value: value})
+	return nil
+}
+
+func describe(path string) string {
+	if path == "" {
+		return "the document"
+	}
+	return path
+}
@@ -0,0 +1,179 @@
+package vars_test
+
+import (
+	"math"
+
+	. "github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Flatten", func() {
+	nested := yaml.MapSlice{
+		{Key: "name", Value: "app"},
+		{Key: "database", Value: yaml.MapSlice{
+			{Key: "host", Value: "x"},
+			{Key: "port", Value: 5432},
+			{Key: "tls", Value: yaml.MapSlice{{Key: "enabled", Value: true}}},
+		}},
+	}
+
+	It("joins nested keys with the separator", func() {
+		flat, err := Flatten(nested, FlattenOptions{Maps: true, Separator: "_"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(flat).Should(Equal(yaml.MapSlice{
+			{Key: "name", Value: "app"},
+			{Key: "database_host", Value: "x"},
+			{Key: "database_port", Value: 5432},
+			{Key: "database_tls_enabled", Value: true},
+		}))
+	})
+
+	It("flattens credentials without flattening other maps", func() {
+		flat, err := Flatten(yaml.MapSlice{
+			{Key: "tls", Value: yaml.MapSlice{{Key: "certificate", Value: "C"}, {Key: "private_key", Value: "K"}}},
+			{Key: "database", Value: yaml.MapSlice{{Key: "host", Value: "x"}}},
+		}, FlattenOptions{Credentials: true, Separator: "_"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(flat).Should(Equal(yaml.MapSlice{
+			{Key: "tls_certificate", Value: "C"},
+			{Key: "tls_private_key", Value: "K"},
+			{Key: "database", Value: yaml.MapSlice{{Key: "host", Value: "x"}}},
+		}))
+	})
+
+	It("uses the given separator", func() {
+		flat, err := Flatten(nested, FlattenOptions{Maps: true, Separator: "__"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(flat).Should(ContainElement(yaml.MapItem{Key: "database__tls__enabled", Value: true}))
+	})
+
+	It("exports maps beyond the depth limit as JSON", func() {
+		flat, err := Flatten(nested, FlattenOptions{Maps: true, Separator: "_", MaxDepth: 1})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(flat).Should(ContainElement(yaml.MapItem{Key: "database_host", Value: "x"}))
+		Ω(flat).Should(ContainElement(yaml.MapItem{Key: "database_tls", Value: `{"enabled":true}`}))
+	})
+
+	It("reports both source paths when flattened keys collide", func() {
+		_, err := Flatten(yaml.MapSlice{
+			{Key: "database", Value: yaml.MapSlice{{Key: "host", Value: "x"}}},
+			{Key: "database_host", Value: "y"},
+		}, FlattenOptions{Maps: true, Separator: "_"})
+		Ω(err).Should(MatchError("database.host and database_host both flatten to database_host"))
+	})
+
+	It("leaves nested maps in place unless asked to flatten them", func() {
+		flat, err := Flatten(nested, FlattenOptions{Separator: "_"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(flat).Should(Equal(nested))
+	})
+
+	Describe("lists", func() {
+		lists := yaml.MapSlice{
+			{Key: "orgs", Value: []interface{}{"a", "b", "c"}},
+			{Key: "ports", Value: []interface{}{80, 443}},
+		}
+
+		It("leaves lists in place without a strategy", func() {
+			flat, err := Flatten(lists, FlattenOptions{Separator: "_"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(flat).Should(Equal(lists))
+		})
+
+		It("joins lists with the delimiter", func() {
+			flat, err := Flatten(lists, FlattenOptions{Separator: "_", Lists: ListJoined, ListDelimiter: ","})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(flat).Should(Equal(yaml.MapSlice{
+				{Key: "orgs", Value: "a,b,c"},
+				{Key: "ports", Value: "80,443"},
+			}))
+		})
+
+		It("indexes lists and adds a count", func() {
+			flat, err := Flatten(lists[:1], FlattenOptions{Separator: "_", Lists: ListIndexed})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(flat).Should(Equal(yaml.MapSlice{
+				{Key: "orgs_0", Value: "a"},
+				{Key: "orgs_1", Value: "b"},
+				{Key: "orgs_2", Value: "c"},
+				{Key: "orgs_count", Value: "3"},
+			}))
+		})
+
+		It("JSON-encodes lists", func() {
+			flat, err := Flatten(lists, FlattenOptions{Separator: "_", Lists: ListJSON})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(flat).Should(Equal(yaml.MapSlice{
+				{Key: "orgs", Value: `["a","b","c"]`},
+				{Key: "ports", Value: `[80,443]`},
+			}))
+		})
+
+		It("JSON-encodes binary data and special floats as their scalar strings", func() {
+			flat, err := Flatten(yaml.MapSlice{
+				{Key: "blobs", Value: []interface{}{[]byte("hello"), math.Inf(1), math.NaN(), 1.5}},
+			}, FlattenOptions{Separator: "_", Lists: ListJSON})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(flat).Should(Equal(yaml.MapSlice{
+				{Key: "blobs", Value: `["hello",".inf",".nan",1.5]`},
+			}))
+		})
+
+		It("lets each key override the default strategy", func() {
+			flat, err := Flatten(lists, FlattenOptions{
+				Separator:      "_",
+				Lists:          ListJoined,
+				ListDelimiter:  " ",
+				ListStrategies: map[string]ListStrategy{"ports": ListJSON},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(flat).Should(Equal(yaml.MapSlice{
+				{Key: "orgs", Value: "a b c"},
+				{Key: "ports", Value: `[80,443]`},
+			}))
+		})
+
+		It("flattens maps inside indexed lists", func() {
+			flat, err := Flatten(yaml.MapSlice{
+				{Key: "spaces", Value: []interface{}{
+					yaml.MapSlice{{Key: "name", Value: "dev"}},
+					yaml.MapSlice{{Key: "name", Value: "prod"}},
+				}},
+			}, FlattenOptions{Maps: true, Separator: "_", Lists: ListIndexed})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(flat).Should(Equal(yaml.MapSlice{
+				{Key: "spaces_0_name", Value: "dev"},
+				{Key: "spaces_1_name", Value: "prod"},
+				{Key: "spaces_count", Value: "2"},
+			}))
+		})
+
+		It("keeps lists that contain secrets secret once they are joined or encoded", func() {
+			secrets := yaml.MapSlice{
+				{Key: "tokens", Value: []interface{}{"public", Secret{Value: "private"}}},
+				{Key: "keys", Value: []interface{}{Secret{Value: "k1"}}},
+			}
+
+			flat, err := Flatten(secrets, FlattenOptions{
+				Separator:      "_",
+				Lists:          ListJoined,
+				ListDelimiter:  ",",
+				ListStrategies: map[string]ListStrategy{"keys": ListJSON},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(flat).Should(Equal(yaml.MapSlice{
+				{Key: "tokens", Value: Secret{Value: "public,private"}},
+				{Key: "keys", Value: Secret{Value: `["k1"]`}},
+			}))
+		})
+
+		It("refuses to join lists of maps", func() {
+			_, err := Flatten(yaml.MapSlice{
+				{Key: "spaces", Value: []interface{}{yaml.MapSlice{{Key: "name", Value: "dev"}}}},
+			}, FlattenOptions{Separator: "_", Lists: ListJoined})
+			Ω(err).Should(MatchError(ContainSubstring("cannot join spaces")))
+		})
+	})
+})
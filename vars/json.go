@@ -0,0 +1,67 @@
+package vars
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// JSON encodes value as compact JSON, keeping the key order of any maps.
+// Scalars that JSON has no form for, such as binary data and infinity, are
+// encoded as the strings Scalar gives for them.
+func JSON(value interface{}) (string, error) {
+	var buffer bytes.Buffer
+	if err := encodeJSON(&buffer, value); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func encodeJSON(buffer *bytes.Buffer, value interface{}) error {
+	switch typed := value.(type) {
+	case yaml.MapSlice:
+		buffer.WriteByte('{')
+		for i, item := range typed {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := encodeJSON(buffer, keyString(item.Key)); err != nil {
+				return err
+			}
+			buffer.WriteByte(':')
+			if err := encodeJSON(buffer, item.Value); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+	case []interface{}:
+		buffer.WriteByte('[')
+		for i, element := range typed {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := encodeJSON(buffer, element); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+	case Secret:
+		return encodeJSON(buffer, typed.Value)
+	case []byte, time.Time:
+		text, _ := Scalar(typed)
+		return encodeJSON(buffer, text)
+	default:
+		if float, isFloat := typed.(float64); isFloat && (math.IsInf(float, 0) || math.IsNaN(float)) {
+			return encodeJSON(buffer, formatFloat(float))
+		}
+		encoded, err := json.Marshal(typed)
+		if err != nil {
+			return err
+		}
+		buffer.Write(encoded)
+	}
+	return nil
+}
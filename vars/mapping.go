@@ -0,0 +1,56 @@
+package vars
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Mapping names the variables to export and the path of the value each one
+// takes, replacing the usual one variable per key.
+type Mapping []MappingEntry
+
+// MappingEntry is a single variable in a Mapping.
+type MappingEntry struct {
+	Name       string
+	Expression string
+	Path       Path
+}
+
+// ParseMapping reads a Mapping from a map of variable names to path
+// expressions, such as {DB_URL: .database.primary.url}.
+func ParseMapping(mapSlice yaml.MapSlice) (Mapping, error) {
+	mapping := Mapping{}
+
+	for _, item := range mapSlice {
+		name := keyString(item.Key)
+		expression, ok := item.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must map to a path", name)
+		}
+
+		path, err := ParsePath(expression)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		mapping = append(mapping, MappingEntry{Name: name, Expression: expression, Path: path})
+	}
+
+	return mapping, nil
+}
+
+// Apply looks up the value of every variable in doc. It is an error for any
+// path to be missing.
+func (m Mapping) Apply(doc yaml.MapSlice) (yaml.MapSlice, error) {
+	mapped := yaml.MapSlice{}
+
+	for _, entry := range m {
+		value, err := Lookup(doc, entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %s", entry.Name, entry.Expression, err)
+		}
+		mapped = append(mapped, yaml.MapItem{Key: entry.Name, Value: value})
+	}
+
+	return mapped, nil
+}
@@ -0,0 +1,46 @@
+package vars_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Mapping", func() {
+	doc := yaml.MapSlice{
+		{Key: "database", Value: yaml.MapSlice{{Key: "url", Value: "postgres://db"}}},
+		{Key: "creds", Value: yaml.MapSlice{
+			{Key: "aws", Value: []interface{}{yaml.MapSlice{{Key: "id", Value: "AKIA"}}}},
+		}},
+		{Key: "unmapped", Value: "x"},
+	}
+
+	It("exports only the mapped variables", func() {
+		mapping, err := ParseMapping(yaml.MapSlice{
+			{Key: "DB_URL", Value: ".database.url"},
+			{Key: "AWS_KEY", Value: ".creds.aws[0].id"},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		mapped, err := mapping.Apply(doc)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(mapped).Should(Equal(yaml.MapSlice{
+			{Key: "DB_URL", Value: "postgres://db"},
+			{Key: "AWS_KEY", Value: "AKIA"},
+		}))
+	})
+
+	It("names the variable and path when a value is missing", func() {
+		mapping, err := ParseMapping(yaml.MapSlice{{Key: "DB_URL", Value: ".database.primary.url"}})
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = mapping.Apply(doc)
+		Ω(err).Should(MatchError(`DB_URL: .database.primary.url: /database has no key "primary"`))
+	})
+
+	It("rejects entries that are not paths", func() {
+		_, err := ParseMapping(yaml.MapSlice{{Key: "DB_URL", Value: 42}})
+		Ω(err).Should(MatchError("DB_URL must map to a path"))
+	})
+})
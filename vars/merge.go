@@ -0,0 +1,95 @@
+package vars
+
+import "gopkg.in/yaml.v2"
+
+// Merge overlays src onto dst, descending into maps present in both. It
+// returns the merged result along with the path of every value in dst that
+// src replaced. Keys repeated within src are merged the same way first, but
+// are not reported. Neither argument is modified.
+func Merge(dst, src yaml.MapSlice) (yaml.MapSlice, []string) {
+	return merge(dst, dedupe(src), "")
+}
+
+// dedupe merges the values of keys that appear more than once in mapSlice,
+// at any depth, so that the last one wins.
+func dedupe(mapSlice yaml.MapSlice) yaml.MapSlice {
+	deduped := yaml.MapSlice{}
+	for _, item := range mapSlice {
+		if nested, ok := item.Value.(yaml.MapSlice); ok {
+			item.Value = dedupe(nested)
+		}
+		deduped, _ = merge(deduped, yaml.MapSlice{item}, "")
+	}
+	return deduped
+}
+
+func merge(dst, src yaml.MapSlice, prefix string) (yaml.MapSlice, []string) {
+	merged := append(yaml.MapSlice{}, dst...)
+	var shadowed []string
+
+	for _, item := range src {
+		path := join(prefix, item.Key)
+		index := indexOf(merged, item.Key)
+
+		if index == -1 {
+			merged = append(merged, item)
+			continue
+		}
+
+		existing := merged[index]
+		existingMap, existingIsMap := existing.Value.(yaml.MapSlice)
+		itemMap, itemIsMap := item.Value.(yaml.MapSlice)
+
+		if existingIsMap && itemIsMap {
+			var nested []string
+			existing.Value, nested = merge(existingMap, itemMap, path)
+			merged[index] = existing
+			shadowed = append(shadowed, nested...)
+		} else {
+			merged[index] = item
+			shadowed = append(shadowed, path)
+		}
+	}
+
+	return merged, shadowed
+}
+
+// Paths lists the path of every leaf value in mapSlice, descending into
+// nested maps.
+func Paths(mapSlice yaml.MapSlice) []string {
+	return paths(mapSlice, "")
+}
+
+func paths(mapSlice yaml.MapSlice, prefix string) []string {
+	var result []string
+
+	for _, item := range mapSlice {
+		path := join(prefix, item.Key)
+		if nested, ok := item.Value.(yaml.MapSlice); ok {
+			result = append(result, paths(nested, path)...)
+		} else {
+			result = append(result, path)
+		}
+	}
+
+	return result
+}
+
+// indexOf compares keys as text, as keys such as lists cannot be compared
+// with ==. Keys read from YAML are the text they were written with, so only
+// keys written the same are merged.
+func indexOf(mapSlice yaml.MapSlice, key interface{}) int {
+	for i, item := range mapSlice {
+		if keyString(item.Key) == keyString(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func join(prefix string, key interface{}) string {
+	if prefix == "" {
+		return keyString(key)
+	}
+	return prefix + "." + keyString(key)
+}
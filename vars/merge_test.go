@@ -0,0 +1,79 @@
+package vars_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Merge", func() {
+	It("appends keys that only appear in the later map", func() {
+		merged, shadowed := Merge(
+			yaml.MapSlice{{Key: "a", Value: "1"}},
+			yaml.MapSlice{{Key: "b", Value: "2"}},
+		)
+		Ω(merged).Should(Equal(yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}}))
+		Ω(shadowed).Should(BeEmpty())
+	})
+
+	It("lets later values win, keeping the original position", func() {
+		merged, shadowed := Merge(
+			yaml.MapSlice{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}},
+			yaml.MapSlice{{Key: "a", Value: "3"}},
+		)
+		Ω(merged).Should(Equal(yaml.MapSlice{{Key: "a", Value: "3"}, {Key: "b", Value: "2"}}))
+		Ω(shadowed).Should(Equal([]string{"a"}))
+	})
+
+	It("merges nested maps and reports the path of shadowed values", func() {
+		merged, shadowed := Merge(
+			yaml.MapSlice{{Key: "db", Value: yaml.MapSlice{{Key: "host", Value: "x"}, {Key: "port", Value: 1}}}},
+			yaml.MapSlice{{Key: "db", Value: yaml.MapSlice{{Key: "port", Value: 2}}}},
+		)
+		Ω(merged).Should(Equal(yaml.MapSlice{{Key: "db", Value: yaml.MapSlice{{Key: "host", Value: "x"}, {Key: "port", Value: 2}}}}))
+		Ω(shadowed).Should(Equal([]string{"db.port"}))
+	})
+
+	It("does not report keys repeated within the later map", func() {
+		merged, shadowed := Merge(
+			yaml.MapSlice{{Key: "db", Value: yaml.MapSlice{{Key: "host", Value: "x"}}}},
+			yaml.MapSlice{
+				{Key: "a", Value: "1"},
+				{Key: "db", Value: yaml.MapSlice{{Key: "port", Value: 1}, {Key: "port", Value: 2}}},
+				{Key: "a", Value: "2"},
+				{Key: "db", Value: yaml.MapSlice{{Key: "host", Value: "y"}}},
+			},
+		)
+		Ω(merged).Should(Equal(yaml.MapSlice{
+			{Key: "db", Value: yaml.MapSlice{{Key: "host", Value: "y"}, {Key: "port", Value: 2}}},
+			{Key: "a", Value: "2"},
+		}))
+		Ω(shadowed).Should(Equal([]string{"db.host"}))
+	})
+
+	It("merges maps with keys that are not scalars", func() {
+		merged, shadowed := Merge(
+			yaml.MapSlice{{Key: []interface{}{"a", "b"}, Value: "c"}},
+			yaml.MapSlice{{Key: []interface{}{"a", "b"}, Value: "d"}},
+		)
+		Ω(merged).Should(Equal(yaml.MapSlice{{Key: []interface{}{"a", "b"}, Value: "d"}}))
+		Ω(shadowed).Should(Equal([]string{"[a b]"}))
+	})
+
+	It("does not modify its arguments", func() {
+		dst := yaml.MapSlice{{Key: "a", Value: "1"}}
+		Merge(dst, yaml.MapSlice{{Key: "a", Value: "2"}})
+		Ω(dst).Should(Equal(yaml.MapSlice{{Key: "a", Value: "1"}}))
+	})
+})
+
+var _ = Describe("Paths", func() {
+	It("lists the path of every leaf", func() {
+		paths := Paths(yaml.MapSlice{
+			{Key: "a", Value: "1"},
+			{Key: "db", Value: yaml.MapSlice{{Key: "host", Value: "x"}}},
+		})
+		Ω(paths).Should(Equal([]string{"a", "db.host"}))
+	})
+})
@@ -0,0 +1,78 @@
+package vars
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Case says how the case of keys is changed when they become names.
+type Case string
+
+const (
+	// CaseUpper uppercases keys, so db_host becomes DB_HOST.
+	CaseUpper Case = "upper"
+	// CasePreserve leaves keys exactly as written.
+	CasePreserve Case = "preserve"
+	// CaseSnake converts camelCase and kebab-case keys to SCREAMING_SNAKE, so
+	// dbHost and db-host both become DB_HOST.
+	CaseSnake Case = "snake"
+)
+
+// ParseCase validates the name of a Case.
+func ParseCase(name string) (Case, error) {
+	switch c := Case(name); c {
+	case CaseUpper, CasePreserve, CaseSnake:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown case %q", name)
+}
+
+// Apply changes the case of key.
+func (c Case) Apply(key string) string {
+	switch c {
+	case CaseUpper:
+		return strings.ToUpper(key)
+	case CaseSnake:
+		return screamingSnake(key)
+	}
+	return key
+}
+
+// Naming turns keys into variable names.
+type Naming struct {
+	// StripPrefix is removed from the start of keys that have it.
+	StripPrefix string
+	Case        Case
+	// Prefix is added to every name exactly as given, after Case is applied.
+	Prefix string
+}
+
+// Name returns the variable name for key.
+func (n Naming) Name(key string) string {
+	return n.Prefix + n.Case.Apply(strings.TrimPrefix(key, n.StripPrefix))
+}
+
+func screamingSnake(key string) string {
+	runes := []rune(key)
+	var builder strings.Builder
+
+	for i, r := range runes {
+		if r == '-' || r == ' ' {
+			builder.WriteRune('_')
+			continue
+		}
+
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextIsLower) {
+				builder.WriteRune('_')
+			}
+		}
+
+		builder.WriteRune(unicode.ToUpper(r))
+	}
+
+	return builder.String()
+}
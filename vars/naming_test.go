@@ -0,0 +1,31 @@
+package vars_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Naming", func() {
+	DescribeTable("naming keys",
+		func(naming Naming, key, expected string) {
+			Ω(naming.Name(key)).Should(Equal(expected))
+		},
+		Entry("uppercase", Naming{Case: CaseUpper}, "db_host", "DB_HOST"),
+		Entry("preserve", Naming{Case: CasePreserve}, "db_Host", "db_Host"),
+		Entry("camelCase", Naming{Case: CaseSnake}, "dbHostName", "DB_HOST_NAME"),
+		Entry("kebab-case", Naming{Case: CaseSnake}, "db-host", "DB_HOST"),
+		Entry("acronyms", Naming{Case: CaseSnake}, "HTTPServerURL", "HTTP_SERVER_URL"),
+		Entry("digits", Naming{Case: CaseSnake}, "s3Bucket", "S3_BUCKET"),
+		Entry("already snake", Naming{Case: CaseSnake}, "DB_HOST", "DB_HOST"),
+		Entry("prefix after case", Naming{Case: CasePreserve, Prefix: "TF_VAR_"}, "db_host", "TF_VAR_db_host"),
+		Entry("prefix with uppercase", Naming{Case: CaseUpper, Prefix: "app_"}, "db_host", "app_DB_HOST"),
+		Entry("stripped prefix", Naming{Case: CaseUpper, StripPrefix: "app_"}, "app_db_host", "DB_HOST"),
+		Entry("stripped prefix missing", Naming{Case: CaseUpper, StripPrefix: "app_"}, "db_host", "DB_HOST"),
+	)
+
+	It("rejects unknown cases", func() {
+		_, err := ParseCase("title")
+		Ω(err).Should(MatchError(`unknown case "title"`))
+	})
+})
@@ -0,0 +1,118 @@
+package vars
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Path addresses a value inside a document, one map key or list index per
+// segment.
+type Path []string
+
+// ParsePath reads either a JSON Pointer such as /environments/staging/cf, or
+// a dotted path such as environments.staging.cf or .creds.aws[0].id.
+func ParsePath(expression string) (Path, error) {
+	if expression == "" {
+		return Path{}, nil
+	}
+
+	if strings.HasPrefix(expression, "/") {
+		segments := strings.Split(expression[1:], "/")
+		for i, segment := range segments {
+			segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)
+		}
+		return Path(segments), nil
+	}
+
+	path := Path{}
+	for _, part := range strings.Split(strings.TrimPrefix(expression, "."), ".") {
+		key := part
+		indexes := ""
+		if bracket := strings.Index(part, "["); bracket != -1 {
+			key, indexes = part[:bracket], part[bracket:]
+		}
+
+		if key == "" && indexes == "" {
+			return nil, fmt.Errorf("path %q has an empty segment", expression)
+		}
+		if key != "" {
+			path = append(path, key)
+		}
+
+		for indexes != "" {
+			end := strings.Index(indexes, "]")
+			if !strings.HasPrefix(indexes, "[") || end == -1 {
+				return nil, fmt.Errorf("path %q has an unterminated index", expression)
+			}
+			index := indexes[1:end]
+			if _, err := strconv.Atoi(index); err != nil {
+				return nil, fmt.Errorf("path %q has a non-numeric index %q", expression, index)
+			}
+			path = append(path, index)
+			indexes = indexes[end+1:]
+		}
+	}
+
+	return path, nil
+}
+
+func (p Path) String() string {
+	if len(p) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(p, "/")
+}
+
+func (p Path) describe() string {
+	if len(p) == 0 {
+		return "the document"
+	}
+	return p.String()
+}
+
+// Lookup finds the value at path within value, which is usually a whole
+// document.
+func Lookup(value interface{}, path Path) (interface{}, error) {
+	for i, segment := range path {
+		switch typed := value.(type) {
+		case yaml.MapSlice:
+			found := false
+			for _, item := range typed {
+				if keyString(item.Key) == segment {
+					value, found = item.Value, true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%s has no key %q", path[:i].describe(), segment)
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(typed) {
+				return nil, fmt.Errorf("%s has no index %q", path[:i].describe(), segment)
+			}
+			value = typed[index]
+		default:
+			return nil, fmt.Errorf("%s is not a map or list", path[:i].describe())
+		}
+	}
+
+	return value, nil
+}
+
+// Select returns the map at path within mapSlice.
+func Select(mapSlice yaml.MapSlice, path Path) (yaml.MapSlice, error) {
+	value, err := Lookup(mapSlice, path)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, ok := value.(yaml.MapSlice)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a map", path.describe())
+	}
+	return selected, nil
+}
@@ -0,0 +1,68 @@
+package vars_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Path", func() {
+	DescribeTable("parsing",
+		func(expression string, expected Path) {
+			path, err := ParsePath(expression)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(path).Should(Equal(expected))
+		},
+		Entry("empty", "", Path{}),
+		Entry("dotted", "environments.staging.cf", Path{"environments", "staging", "cf"}),
+		Entry("leading dot", ".creds.aws[0].id", Path{"creds", "aws", "0", "id"}),
+		Entry("nested indexes", "matrix[1][2]", Path{"matrix", "1", "2"}),
+		Entry("JSON Pointer", "/environments/staging", Path{"environments", "staging"}),
+		Entry("escaped JSON Pointer", "/a~1b/c~0d", Path{"a/b", "c~d"}),
+	)
+
+	DescribeTable("rejecting invalid paths",
+		func(expression string) {
+			_, err := ParsePath(expression)
+			Ω(err).Should(HaveOccurred())
+		},
+		Entry("empty segment", "a..b"),
+		Entry("unterminated index", "a[0"),
+		Entry("non-numeric index", "a[x]"),
+	)
+
+	doc := yaml.MapSlice{
+		{Key: "environments", Value: yaml.MapSlice{
+			{Key: "staging", Value: yaml.MapSlice{{Key: "cf", Value: yaml.MapSlice{{Key: "api", Value: "x"}}}}},
+		}},
+		{Key: "creds", Value: yaml.MapSlice{
+			{Key: "aws", Value: []interface{}{yaml.MapSlice{{Key: "id", Value: "AKIA"}}}},
+		}},
+	}
+
+	It("looks up values through maps and lists", func() {
+		value, err := Lookup(doc, Path{"creds", "aws", "0", "id"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(value).Should(Equal("AKIA"))
+	})
+
+	It("reports where a lookup fails", func() {
+		_, err := Lookup(doc, Path{"environments", "prod", "cf"})
+		Ω(err).Should(MatchError(`/environments has no key "prod"`))
+
+		_, err = Lookup(doc, Path{"creds", "aws", "1"})
+		Ω(err).Should(MatchError(`/creds/aws has no index "1"`))
+	})
+
+	It("selects a nested map", func() {
+		selected, err := Select(doc, Path{"environments", "staging", "cf"})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(selected).Should(Equal(yaml.MapSlice{{Key: "api", Value: "x"}}))
+	})
+
+	It("refuses to select anything but a map", func() {
+		_, err := Select(doc, Path{"creds", "aws"})
+		Ω(err).Should(MatchError("/creds/aws is not a map"))
+	})
+})
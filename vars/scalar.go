@@ -0,0 +1,62 @@
+package vars
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Scalar returns the string form of a scalar value, and false if value is
+// not a scalar. Null becomes the empty string.
+func Scalar(value interface{}) (string, bool) {
+	switch typed := value.(type) {
+	case Secret:
+		return Scalar(typed.Value)
+	case nil:
+		return "", true
+	case string:
+		return typed, true
+	case json.Number:
+		return typed.String(), true
+	case []byte:
+		return string(typed), true
+	case bool:
+		return strconv.FormatBool(typed), true
+	case int:
+		return strconv.Itoa(typed), true
+	case int64:
+		return strconv.FormatInt(typed, 10), true
+	case uint64:
+		return strconv.FormatUint(typed, 10), true
+	case float64:
+		return formatFloat(typed), true
+	case time.Time:
+		return typed.Format(time.RFC3339Nano), true
+	}
+	return "", false
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return ".inf"
+	case math.IsInf(value, -1):
+		return "-.inf"
+	case math.IsNaN(value):
+		return ".nan"
+	}
+	// Exponents are only used where writing the digits out would be absurd.
+	if abs := math.Abs(value); abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func keyString(key interface{}) string {
+	if scalar, ok := Scalar(key); ok {
+		return scalar
+	}
+	return fmt.Sprint(key)
+}
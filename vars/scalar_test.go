@@ -0,0 +1,43 @@
+package vars_test
+
+import (
+	"math"
+	"time"
+
+	. "github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Scalar", func() {
+	DescribeTable("converts scalars to strings",
+		func(value interface{}, expected string) {
+			converted, ok := Scalar(value)
+			Ω(ok).Should(BeTrue())
+			Ω(converted).Should(Equal(expected))
+		},
+		Entry("string", "foo", "foo"),
+		Entry("bool", true, "true"),
+		Entry("int", 42, "42"),
+		Entry("int64", int64(-9223372036854775808), "-9223372036854775808"),
+		Entry("uint64", uint64(18446744073709551615), "18446744073709551615"),
+		Entry("float", 1.5, "1.5"),
+		Entry("whole float", 1e3, "1000"),
+		Entry("large float", 100000000.0, "100000000"),
+		Entry("small float", 0.00001, "0.00001"),
+		Entry("huge float", 1e300, "1e+300"),
+		Entry("tiny float", -1e-300, "-1e-300"),
+		Entry("infinity", math.Inf(1), ".inf"),
+		Entry("null", nil, ""),
+		Entry("binary", []byte("hello"), "hello"),
+		Entry("timestamp", time.Date(2001, 12, 14, 21, 59, 43, 0, time.UTC), "2001-12-14T21:59:43Z"),
+	)
+
+	It("rejects maps and lists", func() {
+		_, ok := Scalar(yaml.MapSlice{})
+		Ω(ok).Should(BeFalse())
+		_, ok = Scalar([]interface{}{})
+		Ω(ok).Should(BeFalse())
+	})
+})
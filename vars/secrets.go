@@ -0,0 +1,102 @@
+package vars
+
+import (
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Mask replaces the values of secret variables in diagnostic output.
+const Mask = "********"
+
+// Secret marks a scalar value that must not be shown in diagnostic output.
+// It stays with the value through merging, selection, mapping and
+// flattening, so that the variable the value ends up in can be masked, and
+// Scalar sees through it.
+type Secret struct {
+	Value interface{}
+}
+
+// MarkSecret marks every scalar within mapSlice as Secret.
+func MarkSecret(mapSlice yaml.MapSlice) yaml.MapSlice {
+	return mark(mapSlice).(yaml.MapSlice)
+}
+
+// MarkSecretAt marks every scalar at or beneath path within mapSlice as
+// Secret. A path that is missing marks nothing.
+func MarkSecretAt(mapSlice yaml.MapSlice, path Path) yaml.MapSlice {
+	return markAt(mapSlice, path).(yaml.MapSlice)
+}
+
+// IsSecret reports whether value is, or contains, a Secret.
+func IsSecret(value interface{}) bool {
+	switch typed := value.(type) {
+	case Secret:
+		return true
+	case yaml.MapSlice:
+		for _, item := range typed {
+			if IsSecret(item.Value) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, element := range typed {
+			if IsSecret(element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mark returns a copy of value with its scalars marked. Empty values are
+// never marked, as there is nothing to hide.
+func mark(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case Secret:
+		return typed
+	case yaml.MapSlice:
+		marked := make(yaml.MapSlice, len(typed))
+		for i, item := range typed {
+			marked[i] = yaml.MapItem{Key: item.Key, Value: mark(item.Value)}
+		}
+		return marked
+	case []interface{}:
+		marked := make([]interface{}, len(typed))
+		for i, element := range typed {
+			marked[i] = mark(element)
+		}
+		return marked
+	}
+
+	if text, ok := Scalar(value); ok && text != "" {
+		return Secret{Value: value}
+	}
+	return value
+}
+
+func markAt(value interface{}, path Path) interface{} {
+	if len(path) == 0 {
+		return mark(value)
+	}
+
+	switch typed := value.(type) {
+	case yaml.MapSlice:
+		marked := append(yaml.MapSlice{}, typed...)
+		for i, item := range marked {
+			if keyString(item.Key) == path[0] {
+				marked[i].Value = markAt(item.Value, path[1:])
+			}
+		}
+		return marked
+	case []interface{}:
+		index, err := strconv.Atoi(path[0])
+		if err != nil || index < 0 || index >= len(typed) {
+			return typed
+		}
+		marked := append([]interface{}{}, typed...)
+		marked[index] = markAt(marked[index], path[1:])
+		return marked
+	}
+	return value
+}
@@ -0,0 +1,49 @@
+package vars_test
+
+import (
+	. "github.com/EngineerBetter/yml2env/vars"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = Describe("Secrets", func() {
+	doc := yaml.MapSlice{
+		{Key: "password", Value: "hunter2"},
+		{Key: "pins", Value: []interface{}{1234, 5678}},
+		{Key: "user", Value: "admin"},
+		{Key: "empty", Value: nil},
+	}
+
+	It("marks every scalar within a document", func() {
+		marked := MarkSecret(doc)
+
+		Ω(marked).Should(Equal(yaml.MapSlice{
+			{Key: "password", Value: Secret{Value: "hunter2"}},
+			{Key: "pins", Value: []interface{}{Secret{Value: 1234}, Secret{Value: 5678}}},
+			{Key: "user", Value: Secret{Value: "admin"}},
+			{Key: "empty", Value: nil},
+		}))
+		Ω(doc[0].Value).Should(Equal("hunter2"))
+	})
+
+	It("marks the values at a path", func() {
+		marked := MarkSecretAt(doc, Path{"pins", "1"})
+		Ω(marked[1].Value).Should(Equal([]interface{}{1234, Secret{Value: 5678}}))
+		Ω(IsSecret(marked[0].Value)).Should(BeFalse())
+
+		Ω(MarkSecretAt(doc, Path{"missing"})).Should(Equal(doc))
+	})
+
+	It("finds secrets within maps and lists", func() {
+		Ω(IsSecret(MarkSecretAt(doc, Path{"pins", "0"}))).Should(BeTrue())
+		Ω(IsSecret(doc)).Should(BeFalse())
+	})
+
+	It("reads secrets as the scalars they mark", func() {
+		text, ok := Scalar(Secret{Value: 1234})
+		Ω(ok).Should(BeTrue())
+		Ω(text).Should(Equal("1234"))
+		Ω(JSON([]interface{}{Secret{Value: 1234}, Secret{Value: "x"}})).Should(Equal(`[1234,"x"]`))
+	})
+})
@@ -2,18 +2,21 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
-	"strconv"
-	"strings"
+	"path/filepath"
 	"syscall"
 
+	"filippo.io/age"
+	"github.com/EngineerBetter/yml2env/crypt"
 	"github.com/EngineerBetter/yml2env/env"
+	"github.com/EngineerBetter/yml2env/input"
+	"github.com/EngineerBetter/yml2env/source"
+	"github.com/EngineerBetter/yml2env/vars"
 	"gopkg.in/yaml.v2"
 )
 
-var usage = "yml2env <YAML file> [<command> | --env]"
+var usage = "yml2env <YAML file> [<command> | --env]\n       yml2env [options] -f <YAML file> [-f <YAML file>]... [<command> | --eval]\n       yml2env encrypt -r <recipient> [-i] <YAML file> [<path>...]\n       yml2env decrypt --identity <file> [-i] <YAML file> [<path>...]"
 
 func main() {
 	args := os.Args
@@ -23,34 +26,55 @@ func main() {
 		os.Exit(0)
 	}
 
-	if len(args) < 3 {
-		fmt.Fprintln(os.Stderr, usage)
-		os.Exit(1)
+	if len(args) > 1 && (args[1] == "encrypt" || args[1] == "decrypt") {
+		if err := runEdit(args[1], args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
 	}
 
-	yamlPath := args[1]
-
-	if !fileExists(yamlPath) {
-		fmt.Fprintln(os.Stderr, yamlPath+" does not exist")
+	opts, command, err := parseArgs(args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageText())
 		os.Exit(1)
 	}
 
-	bytes := loadYaml(yamlPath)
-	mapSlice := parseYaml(bytes)
-	mapSlice = uppercaseKeys(mapSlice)
-	envVars := os.Environ()
-	envVars = addToEnv(mapSlice, envVars)
+	mapSlice, keyOrigins, verbatim := loadFiles(opts)
+	opts.naming.Case = effectiveCase(opts, verbatim)
+	mapSlice, err = vars.Select(mapSlice, opts.selection)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not select "+opts.selectPath+": "+err.Error())
+		os.Exit(1)
+	}
 
-	if args[2] == "--eval" {
-		if len(args) > 3 {
-			fmt.Fprintln(os.Stderr, usage)
+	if opts.mappingPath != "" {
+		mapping := loadMapping(opts.mappingPath, opts.sources)
+		mapSlice, err = mapping.Apply(mapSlice)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Could not apply mapping: "+err.Error())
 			os.Exit(1)
 		}
+	}
 
+	mapSlice, err = vars.Flatten(mapSlice, opts.flattening)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	mapSlice = nameKeys(mapSlice, keyOrigins, opts)
+	if opts.verbose {
+		printVerbose(mapSlice)
+	}
+	envVars := os.Environ()
+	envVars = addToEnv(mapSlice, envVars)
+
+	if opts.eval {
 		printExports(mapSlice)
 		os.Exit(0)
 	} else {
-		err, _ := run(envVars, args[2:])
+		err, _ := run(envVars, command)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
@@ -58,56 +82,302 @@ func main() {
 	}
 }
 
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
+// loadFiles marks the values that must not be shown as vars.Secret. It
+// also gives the file each variable came from by its flattened key, and
+// reports whether every file held variables whose names should be kept as
+// they are.
+func loadFiles(opts options) (yaml.MapSlice, map[string]string, bool) {
+	mapSlice := yaml.MapSlice{}
+	origins := map[string]string{}
+	keyOrigins := map[string]string{}
+	verbatim := true
+	var checks []keyCheck
+
+	for _, location := range opts.files {
+		bytes := readSource(location, opts.sources)
+		name, secret := source.Name(location), source.IsSecretStore(location)
+		if crypt.IsGPG(bytes) {
+			secret = true
+			bytes = decryptGPG(location, bytes)
+			name = crypt.TrimGPGExtension(name)
+		}
+		format := opts.format
+		if source.IsSecretStore(location) {
+			// Secret stores always give JSON, whatever their paths look like.
+			format = input.FormatJSON
+		} else if format == input.FormatAuto {
+			format = input.Detect(name, bytes)
+		}
+		verbatim = verbatim && (format == input.FormatKubernetes || opts.composeService != "")
+
+		if opts.strict && (format == input.FormatYAML || format == input.FormatJSON || format == input.FormatKubernetes) {
+			checks = append(checks, keyCheck{location, bytes})
+		}
+
+		var decrypted [][]vars.Path
+		if crypt.HasEncryptedValues(bytes) && (format == input.FormatYAML || format == input.FormatKubernetes || format == input.FormatCredHub || opts.composeService != "") {
+			bytes, decrypted = decryptValues(location, bytes, opts.identity)
+		}
 
-	if err != nil && os.IsNotExist(err) {
-		return false
+		var documents []input.Document
+		if opts.composeService != "" {
+			documents = parseCompose(location, bytes, opts.composeService, decrypted)
+		} else {
+			documents = parseDocuments(location, format, bytes, opts.raw, secret, decrypted)
+		}
+		if crypt.IsSOPS(documents) {
+			documents = decryptSOPS(location, documents)
+		}
+		documents, indexes, err := opts.documents.Select(documents)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, location+": "+err.Error())
+			os.Exit(1)
+		}
+
+		for i, doc := range documents {
+			origin := location
+			if len(documents) > 1 {
+				origin = fmt.Sprintf("%s (document %d)", location, indexes[i])
+			}
+
+			if secret {
+				doc.Vars = vars.MarkSecret(doc.Vars)
+			}
+			for _, path := range doc.Sensitive {
+				doc.Vars = vars.MarkSecretAt(doc.Vars, path)
+			}
+
+			mapSlice = mergeDocument(mapSlice, doc.Vars, origin, origins, opts.conflict)
+			recordKeyOrigins(keyOrigins, doc.Vars, origin, opts.flattening)
+		}
+	}
+
+	// Whether keys collide depends on the case of names, which depends on
+	// the formats of every file.
+	normalise := effectiveCase(opts, verbatim).Apply
+	for _, check := range checks {
+		if err := input.CheckKeys(check.bytes, normalise); err != nil {
+			fmt.Fprintln(os.Stderr, check.location+": "+err.Error())
+			os.Exit(1)
+		}
+	}
+
+	return mapSlice, keyOrigins, verbatim
+}
+
+type keyCheck struct {
+	location string
+	bytes    []byte
+}
+
+// effectiveCase keeps keys as they are when Kubernetes and Compose would,
+// as they give the keys of Secrets, ConfigMaps and service environments to
+// containers unchanged.
+func effectiveCase(opts options, verbatim bool) vars.Case {
+	if verbatim && !opts.caseSet {
+		return vars.CasePreserve
+	}
+	return opts.naming.Case
+}
+
+func mergeDocument(mapSlice, doc yaml.MapSlice, origin string, origins map[string]string, conflict string) yaml.MapSlice {
+	mapSlice, shadowed := vars.Merge(mapSlice, doc)
+
+	for _, key := range shadowed {
+		reportConflict(fmt.Sprintf("%s in %s overrides the value from %s", key, origin, origins[key]), conflict)
 	}
 
-	return true
+	for _, key := range vars.Paths(doc) {
+		origins[key] = origin
+	}
+
+	return mapSlice
 }
 
-func loadYaml(yamlPath string) []byte {
-	bytes, err := ioutil.ReadFile(yamlPath)
+// recordKeyOrigins records origin as the file each variable in doc came
+// from, by the key it has once flattened, so that keys from different files
+// that only become the same variable once named can be told apart from keys
+// within one file.
+func recordKeyOrigins(keyOrigins map[string]string, doc yaml.MapSlice, origin string, flattening vars.FlattenOptions) {
+	// Keys that cannot be flattened are reported once every file is merged.
+	flat, err := vars.Flatten(doc, flattening)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Could not read "+yamlPath)
+		return
+	}
+	for _, item := range flat {
+		if key, ok := item.Key.(string); ok {
+			keyOrigins[key] = origin
+		}
+	}
+}
+
+func reportConflict(message, conflict string) {
+	if conflict == conflictError {
+		fmt.Fprintln(os.Stderr, "Conflicting keys: "+message)
+		os.Exit(1)
+	} else if conflict == conflictWarn {
+		fmt.Fprintln(os.Stderr, "Warning: "+message)
+	}
+}
+
+func loadMapping(location string, sourceOpts source.Options) vars.Mapping {
+	mapping, err := vars.ParseMapping(parseYaml(readSource(location, sourceOpts)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Mapping invalid: "+err.Error())
+		os.Exit(1)
+	}
+
+	return mapping
+}
+
+func readSource(location string, sourceOpts source.Options) []byte {
+	bytes, err := source.Read(location, sourceOpts)
+	if err == source.ErrNotExist {
+		fmt.Fprintln(os.Stderr, location+" does not exist")
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not read "+location+": "+err.Error())
+		os.Exit(1)
 	}
 	return bytes
 }
 
 func parseYaml(bytes []byte) yaml.MapSlice {
-	vars := yaml.MapSlice{}
-	err := yaml.Unmarshal([]byte(bytes), &vars)
-
+	mapSlice, err := input.YAML(bytes)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Could not parse YAML")
 		os.Exit(1)
 	}
 
-	return vars
+	return mapSlice
+}
+
+// parseDocuments leaves out why a secret file could not be parsed, as parse
+// errors can quote the values in it.
+func parseDocuments(location string, format input.Format, bytes []byte, raw bool, secret bool, decrypted [][]vars.Path) []input.Document {
+	documents, err := input.Parse(format, bytes, raw, decrypted)
+	if err != nil && secret {
+		fmt.Fprintf(os.Stderr, "Could not parse %s as %s\n", location, format)
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not parse %s as %s: %s\n", location, format, err)
+		os.Exit(1)
+	}
+
+	return documents
+}
+
+func decryptGPG(location string, bytes []byte) []byte {
+	plaintext, err := crypt.GPG(bytes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not decrypt %s with gpg: %s\n", location, err)
+		os.Exit(1)
+	}
+
+	return plaintext
+}
+
+func decryptSOPS(location string, documents []input.Document) []input.Document {
+	documents, err := crypt.SOPS(documents)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not decrypt %s with SOPS: %s\n", location, err)
+		os.Exit(1)
+	}
+
+	return documents
+}
+
+func decryptValues(location string, bytes []byte, identity string) ([]byte, [][]vars.Path) {
+	var identities []age.Identity
+	if identity != "" {
+		var err error
+		if identities, err = crypt.ReadIdentities(identity); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not read the age identity: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	decrypted, paths, err := crypt.DecryptValues(bytes, nil, identities)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, location+": "+err.Error())
+		if identity == "" {
+			fmt.Fprintln(os.Stderr, "Give an age identity with --identity or "+identityEnv)
+		}
+		os.Exit(1)
+	}
+
+	return decrypted, paths
+}
+
+func parseCompose(location string, bytes []byte, service string, decrypted [][]vars.Path) []input.Document {
+	// Env files are only read from disk, next to compose files that are.
+	dir := ""
+	if location == source.Stdin {
+		dir = "."
+	} else if !source.IsURL(location) && !source.IsSecretStore(location) {
+		dir = filepath.Dir(location)
+	}
+
+	// Only the first document of a compose file is read.
+	var paths []vars.Path
+	if len(decrypted) > 0 {
+		paths = decrypted[0]
+	}
+
+	document, err := input.Compose(bytes, service, dir, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read %s as a compose file: %s\n", location, err)
+		os.Exit(1)
+	}
+
+	return []input.Document{document}
 }
 
 func valueToString(item yaml.MapItem) yaml.MapItem {
-	if value, ok := item.Value.(bool); ok {
-		item.Value = strconv.FormatBool(value)
-	} else if value, ok := item.Value.(int); ok {
-		item.Value = strconv.Itoa(value)
+	if value, ok := vars.Scalar(item.Value); ok {
+		item.Value = value
 	}
 	return item
 }
 
-func uppercaseKeys(mapSlice yaml.MapSlice) yaml.MapSlice {
+func nameKeys(mapSlice yaml.MapSlice, keyOrigins map[string]string, opts options) yaml.MapSlice {
+	seen := map[string]sanitisedName{}
+	names := map[string]string{}
+
 	for i := 0; i < len(mapSlice); i++ {
 		item := mapSlice[i]
 
 		if key, ok := item.Key.(string); ok {
-			key := strings.ToUpper(key)
+			name := opts.naming.Name(key)
+			if opts.sanitise {
+				name = sanitiseName(name, key, seen)
+			} else if !env.ValidName(name) {
+				fmt.Fprintln(os.Stderr, "'"+name+"' is not a valid variable name; use --sanitise to replace invalid characters with underscores")
+				os.Exit(1)
+			}
+
+			if previous, found := names[name]; found && opts.strict {
+				fmt.Fprintln(os.Stderr, "Keys '"+previous+"' and '"+key+"' both become "+name)
+				os.Exit(1)
+			} else if found && keyOrigins[previous] != "" && keyOrigins[key] != "" && keyOrigins[previous] != keyOrigins[key] {
+				// Keys that only become the same variable once named are
+				// not merged, so the later file wins without this.
+				reportConflict(fmt.Sprintf("%s in %s overrides %s from %s, as both become %s", key, keyOrigins[key], previous, keyOrigins[previous], name), opts.conflict)
+			}
+			names[name] = key
+
+			secret := vars.IsSecret(item.Value)
 			item = valueToString(item)
 			if value, ok := item.Value.(string); ok {
-				mapSlice[i] = yaml.MapItem{Key: key, Value: value}
+				mapSlice[i] = yaml.MapItem{Key: name, Value: value}
+				if secret {
+					mapSlice[i].Value = vars.Secret{Value: value}
+				}
+			} else if _, isList := item.Value.([]interface{}); isList {
+				fmt.Fprintln(os.Stderr, "'"+key+"' is a list; use --list-strategy to export it")
+				os.Exit(1)
 			} else {
-				fmt.Fprintln(os.Stderr, "YAML invalid")
+				fmt.Fprintln(os.Stderr, "'"+key+"' is a map; use --flatten to export its keys")
 				os.Exit(1)
 			}
 		} else {
@@ -119,6 +389,26 @@ func uppercaseKeys(mapSlice yaml.MapSlice) yaml.MapSlice {
 	return mapSlice
 }
 
+type sanitisedName struct {
+	key     string
+	changed bool
+}
+
+// sanitiseName refuses to let sanitising make two different keys share a
+// name, which would otherwise silently drop one of them.
+func sanitiseName(name, key string, seen map[string]sanitisedName) string {
+	sanitised := env.SanitiseName(name)
+	changed := sanitised != name
+
+	if previous, found := seen[sanitised]; found && (previous.changed || changed) {
+		fmt.Fprintln(os.Stderr, "Keys '"+previous.key+"' and '"+key+"' both sanitise to "+sanitised)
+		os.Exit(1)
+	}
+	seen[sanitised] = sanitisedName{key: key, changed: changed}
+
+	return sanitised
+}
+
 func addToEnv(mapSlice yaml.MapSlice, envVars []string) []string {
 	for i := 0; i < len(mapSlice); i++ {
 		item := mapSlice[i]
@@ -175,10 +465,20 @@ func printExports(mapSlice yaml.MapSlice) {
 		item := mapSlice[i]
 
 		key, _ := item.Key.(string)
-		key = strings.ToUpper(key)
 		item = valueToString(item)
 		value, _ := item.Value.(string)
-		fmt.Printf("export '%s=%s'\n", key, value)
+		fmt.Println("export " + env.Quote(key+"="+value))
+	}
+}
+
+func printVerbose(mapSlice yaml.MapSlice) {
+	for _, item := range mapSlice {
+		key, _ := item.Key.(string)
+		value, _ := vars.Scalar(item.Value)
+		if vars.IsSecret(item.Value) {
+			value = vars.Mask
+		}
+		fmt.Fprintln(os.Stderr, "Setting "+key+"="+value)
 	}
 }
 